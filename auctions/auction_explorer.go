@@ -1,32 +1,36 @@
 package auctions
 
 import (
-	"bytes"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	"skyblock-pv-backend/internal"
 	"skyblock-pv-backend/utils"
-	"skyblock-pv-backend/utils/nbt"
+	"skyblock-pv-backend/utils/text"
 	"slices"
 	"strings"
+	"sync"
 	"time"
 )
 
-const AuthCacheVersion = 1
+// AuthCacheVersion is bumped whenever the keys or the shape of the cached and served prices change.
+// 2 added the fingerprint variants of items, 3 keys single enchanted books as enchantment:<name>:<level>.
+const AuthCacheVersion = 3
 
 func withCacheVersion(str string) string { return fmt.Sprintf("%s_%d", str, AuthCacheVersion) }
 
 type opMode interface {
-	GetAuctions(ctx *internal.RouteContext) ([]AuctionStruct, error)
+	GetAuctions(ctx *internal.RouteContext, recorder *runRecorder) ([]AuctionStruct, error)
 	Add(ctx *internal.RouteContext, respond *AuctionRespond)
 	Finish(ctx *internal.RouteContext)
 	Debug(page int)
+	LastUpdated() int64
 }
 
 type prod struct {
 	opMode
-	auctions []AuctionStruct
+	auctions    []AuctionStruct
+	lastUpdated int64
 }
 
 func (prod *prod) Add(_ *internal.RouteContext, response *AuctionRespond) {
@@ -34,12 +38,17 @@ func (prod *prod) Add(_ *internal.RouteContext, response *AuctionRespond) {
 		prod.auctions = make([]AuctionStruct, 0)
 	}
 	prod.auctions = append(prod.auctions, response.Auctions...)
+	prod.lastUpdated = max(prod.lastUpdated, response.LastUpdated)
+}
+
+func (prod *prod) LastUpdated() int64 {
+	return prod.lastUpdated
 }
 
 func (prod *prod) Finish(_ *internal.RouteContext) {}
 
-func (prod *prod) GetAuctions(ctx *internal.RouteContext) ([]AuctionStruct, error) {
-	err := fetch(*ctx, prod)
+func (prod *prod) GetAuctions(ctx *internal.RouteContext, recorder *runRecorder) ([]AuctionStruct, error) {
+	err := fetch(*ctx, prod, recorder)
 	if err != nil {
 		return nil, err
 	}
@@ -50,8 +59,9 @@ func (prod *prod) Debug(_ int) {}
 
 type dev struct {
 	opMode
-	auctions []AuctionStruct
-	Duration time.Duration
+	auctions    []AuctionStruct
+	lastUpdated int64
+	Duration    time.Duration
 }
 
 func (dev *dev) Add(ctx *internal.RouteContext, response *AuctionRespond) {
@@ -60,16 +70,21 @@ func (dev *dev) Add(ctx *internal.RouteContext, response *AuctionRespond) {
 		dev.auctions = make([]AuctionStruct, 0)
 	}
 	dev.auctions = append(dev.auctions, response.Auctions...)
+	dev.lastUpdated = max(dev.lastUpdated, response.LastUpdated)
+}
+
+func (dev *dev) LastUpdated() int64 {
+	return dev.lastUpdated
 }
 
 func (dev *dev) Finish(ctx *internal.RouteContext) {
 	_ = ctx.AddToCache(withCacheVersion("auctions"), "cached", "<3", dev.Duration)
 }
 
-func (dev *dev) GetAuctions(ctx *internal.RouteContext) ([]AuctionStruct, error) {
-	fmt.Println("Using dev mode, PLEASE DONT USE IN PROD :sob:")
+func (dev *dev) GetAuctions(ctx *internal.RouteContext, recorder *runRecorder) ([]AuctionStruct, error) {
+	ctx.Logger.Warn("Using dev mode, PLEASE DONT USE IN PROD :sob:")
 	if ctx.IsCached(withCacheVersion("auctions"), "cached") {
-		fmt.Println("Retrieving previously cached data")
+		ctx.Logger.Info("Retrieving previously cached data")
 		data, err := dev.readCached(ctx)
 		if err != nil {
 			return nil, err
@@ -77,7 +92,7 @@ func (dev *dev) GetAuctions(ctx *internal.RouteContext) ([]AuctionStruct, error)
 		return *data, nil
 	}
 
-	err := fetch(*ctx, dev)
+	err := fetch(*ctx, dev, recorder)
 	if err != nil {
 		return nil, err
 	}
@@ -85,7 +100,7 @@ func (dev *dev) GetAuctions(ctx *internal.RouteContext) ([]AuctionStruct, error)
 }
 
 func (dev *dev) Debug(page int) {
-	fmt.Printf("Fetching page %d\n", page)
+	slog.Debug("Fetching auction page", "page", page)
 }
 
 func (dev *dev) readCached(ctx *internal.RouteContext) (*[]AuctionStruct, error) {
@@ -93,7 +108,7 @@ func (dev *dev) readCached(ctx *internal.RouteContext) (*[]AuctionStruct, error)
 	if err != nil {
 		return nil, err
 	}
-	fmt.Printf("Loading %d auctions from cache\n", len(auctions))
+	ctx.Logger.Info("Loading auctions from cache", "auctions", len(auctions))
 	dev.auctions = make([]AuctionStruct, 0)
 	for _, auctionJson := range auctions {
 		var auction AuctionStruct
@@ -122,45 +137,98 @@ func FetchAll(ctx *internal.RouteContext) error {
 		opMode = &prod{}
 	}
 
-	auctions, err := opMode.GetAuctions(ctx)
+	recorder := newRunRecorder(FullRun)
+	auctions, err := opMode.GetAuctions(ctx, recorder)
+	if err != nil {
+		recorder.finish(err)
+		return err
+	}
+	fingerprinter, err := newFingerprinter(ctx)
 	if err != nil {
+		recorder.finish(err)
 		return err
 	}
+	live.replace(auctions, fingerprinter, opMode.LastUpdated())
 
-	items := calculateAverage(auctions)
-	data, err := json.Marshal(*items)
+	items, err := publish(ctx, live.snapshot(), opMode.LastUpdated())
+	recorder.update(func(stats *RunStats) {
+		stats.Auctions = len(auctions)
+		if items != nil {
+			stats.Items = len(*items)
+		}
+	})
+	recorder.finish(err)
 	if err != nil {
 		return err
 	}
 
-	_ = ctx.AddToCache(withCacheVersion("auctions"), "cached", data, time.Hour*2)
+	if err := storeHistory(ctx, *items, time.Now()); err != nil {
+		ctx.Logger.Error("Failed to store auction history", "err", err)
+	}
+	if err := pruneOldSales(ctx); err != nil {
+		ctx.Logger.Error("Failed to prune auction sales", "err", err)
+	}
 
-	fmt.Println("Finished updating!")
+	ctx.Logger.Info("Finished updating auctions", "auctions", len(auctions), "items", len(*items))
 	return nil
 }
 
-func calculateAverage(auctions []AuctionStruct) *map[string]ItemInfo {
+func newFingerprinter(ctx *internal.RouteContext) (*utils.Fingerprinter, error) {
+	return utils.NewFingerprinter(ctx.Config.Auctions.Fingerprint, ctx.Config.Auctions.FingerprintEnchantments)
+}
+
+// publish calculates the prices of the auctions and makes them available to the routes
+func publish(ctx *internal.RouteContext, auctions []indexedAuction, lastUpdated int64) (*map[string]ItemInfo, error) {
+	index := newSearchIndex(auctions, lastUpdated)
+	currentSearchIndex.Store(index)
+	ctx.Logger.Debug("Calculating prices", "auctions", len(index.auctions))
+	items := calculateAverage(index)
+	// the previous statistics are still returned if they couldn't be recalculated
+	stats, err := soldStats.get(ctx)
+	if err != nil {
+		ctx.Logger.Error("Failed to calculate sold prices", "err", err)
+	}
+	addSoldStats(*items, stats)
+
+	data, err := json.Marshal(*items)
+	if err != nil {
+		return nil, err
+	}
+
+	_ = ctx.AddToCache(withCacheVersion("auctions"), "cached", data, time.Hour*2)
+	setPriceIndex(string(data), *items)
+	return items, nil
+}
+
+// calculateAverage groups the prices by sb id, variants with a different fingerprint are additionally grouped on their own
+func calculateAverage(index *searchIndex) *map[string]ItemInfo {
 	var items = make(map[string][]int64)
+	// the rarity is taken from the cheapest auction, recombobulated variants have their own fingerprint
+	var rarities = make(map[string]string)
+	var cheapest = make(map[string]int64)
 
-	fmt.Printf("Searching %d auctions\n", len(auctions))
-	for _, auction := range auctions {
-		if !auction.Bin {
+	for _, auction := range index.auctions {
+		if !auction.Bin || auction.sbId == "" {
 			continue
 		}
-		item, err := auction.GetItem()
-		if err != nil {
-			continue
-		}
-		sbId := item.GetSbId()
-		if sbId == nil {
-			continue
-		}
-		priceList := items[*sbId]
-		if priceList == nil {
-			priceList = make([]int64, 0)
+		keys := []string{auction.sbId}
+		if auction.fingerprint != "" {
+			keys = append(keys, auction.fingerprint)
 		}
 
-		items[*sbId] = append(priceList, auction.StartingBid/int64(item.Count()))
+		price := auction.StartingBid / int64(auction.count)
+		for _, key := range keys {
+			priceList := items[key]
+			if priceList == nil {
+				priceList = make([]int64, 0)
+			}
+			if lowest, ok := cheapest[key]; auction.rarity != "" && (!ok || price < lowest) {
+				rarities[key] = auction.rarity
+				cheapest[key] = price
+			}
+
+			items[key] = append(priceList, price)
+		}
 	}
 
 	actualItems := make(map[string]ItemInfo)
@@ -172,6 +240,7 @@ func calculateAverage(auctions []AuctionStruct) *map[string]ItemInfo {
 			Lowest:  cost[0],
 			Median:  calculateMedian(cost),
 			Mean:    calculateArithmeticMean(cost),
+			Rarity:  rarities[key],
 		}
 	}
 
@@ -204,6 +273,10 @@ type ItemInfo struct {
 	Highest int64   `json:"highest"`
 	Median  int64   `json:"median"`
 	Mean    float64 `json:"mean"`
+	// Rarity is the rarity of the cheapest auction
+	Rarity string `json:"rarity,omitempty"`
+	// Sold maps the windows in SoldWindows to the prices the item sold for, missing without recent sales
+	Sold map[string]SoldInfo `json:"sold,omitempty"`
 }
 
 func cacheAll(ctx *internal.RouteContext, auction *AuctionRespond) {
@@ -215,49 +288,114 @@ func cacheAll(ctx *internal.RouteContext, auction *AuctionRespond) {
 func cache(ctx internal.RouteContext, auction *AuctionStruct) {
 	data, err := json.Marshal(auction)
 	if err != nil {
-		println(err.Error())
+		ctx.Logger.Error("Failed to encode auction", "auction", auction.Id, "err", err)
 		return
 	}
 	_ = ctx.AddToCache(withCacheVersion("auctions.index"), auction.Id, data, time.Hour*7)
 }
 
-func fetch(ctx internal.RouteContext, mode opMode) error {
-	data, err := fetchPage(ctx, 0, &mode)
+type pageResult struct {
+	page int
+	data *AuctionRespond
+	err  error
+}
+
+// fetch downloads all pages using a pool of workers, the snapshot is rejected if too many pages failed
+func fetch(ctx internal.RouteContext, mode opMode, recorder *runRecorder) error {
+	config := ctx.Config.Auctions
+	data, err := fetchPageWithRetries(ctx, 0, &mode, recorder)
 	if err != nil {
+		recorder.update(func(stats *RunStats) {
+			stats.FailedPages = append(stats.FailedPages, 0)
+		})
 		return err
 	}
 	mode.Add(&ctx, data)
-
-	for i := range data.TotalPages {
-		if i == 0 {
-			continue
+	recorder.update(func(stats *RunStats) {
+		stats.TotalPages = data.TotalPages
+		stats.Pages++
+	})
+
+	pages := make(chan int)
+	results := make(chan pageResult)
+	workers := sync.WaitGroup{}
+	for range min(config.Workers, max(data.TotalPages-1, 1)) {
+		workers.Go(func() {
+			for page := range pages {
+				data, err := fetchPageWithRetries(ctx, page, &mode, recorder)
+				results <- pageResult{page, data, err}
+			}
+		})
+	}
+	go func() {
+		for page := 1; page < data.TotalPages; page++ {
+			pages <- page
 		}
-		data, err = fetchPage(ctx, i, &mode)
-		if err != nil {
-			fmt.Printf("so sad^%d", i)
-			return err
+		close(pages)
+		workers.Wait()
+		close(results)
+	}()
+
+	failed := make([]int, 0)
+	for result := range results {
+		if result.err != nil {
+			ctx.Logger.Error("Failed to fetch auction page", "page", result.page, "err", result.err)
+			failed = append(failed, result.page)
+			continue
 		}
-		mode.Add(&ctx, data)
+		mode.Add(&ctx, result.data)
+	}
+
+	slices.Sort(failed)
+	succeeded := data.TotalPages - len(failed)
+	recorder.update(func(stats *RunStats) {
+		stats.Pages = succeeded
+		stats.FailedPages = failed
+	})
+	if len(failed) > 0 && float64(succeeded)/float64(data.TotalPages) < config.MinPageRatio {
+		return fmt.Errorf("only %d of %d auction pages succeeded, keeping the previous snapshot", succeeded, data.TotalPages)
 	}
+
 	mode.Finish(&ctx)
-	return err
+	return nil
+}
+
+func fetchPageWithRetries(ctx internal.RouteContext, page int, mode *opMode, recorder *runRecorder) (*AuctionRespond, error) {
+	config := ctx.Config.Auctions
+	delay := time.Duration(config.RetryDelayMilli) * time.Millisecond
+	data, err := fetchPage(ctx, page, mode)
+	for attempt := 0; err != nil && attempt < config.Retries; attempt++ {
+		select {
+		case <-time.After(delay):
+		case <-(*ctx.Context).Done():
+			return nil, (*ctx.Context).Err()
+		}
+		delay *= 2
+		recorder.update(func(stats *RunStats) {
+			stats.Retries++
+		})
+		data, err = fetchPage(ctx, page, mode)
+	}
+	return data, err
 }
 
 func fetchPage(ctx internal.RouteContext, page int, mode *opMode) (*AuctionRespond, error) {
-	(*mode).Debug(page)
+	if mode != nil {
+		(*mode).Debug(page)
+	}
 	res, err := internal.GetFromHypixel(ctx, fmt.Sprintf("/v2/skyblock/auctions?page=%d", page), false)
 	if err != nil {
-		println("Error fetching data from hypixel")
+		ctx.Logger.Warn("Failed to fetch auction page from hypixel", "page", page, "err", err)
 		return nil, err
 	} else if res == nil {
-		println("No data received from hypixel")
+		ctx.Logger.Warn("No data received from hypixel", "page", page)
 		return nil, fmt.Errorf("no data received from hypixel")
 	}
 
 	var auctionRespond AuctionRespond
 	err = json.NewDecoder(strings.NewReader(*res)).Decode(&auctionRespond)
 	if err != nil {
-		println("Error decoding data from hypixel")
+		ctx.Logger.Warn("Failed to decode auction page from hypixel", "page", page, "err", err)
 		return nil, err
 	}
 
@@ -276,20 +414,33 @@ type AuctionRespond struct {
 type AuctionStruct struct {
 	Bin         bool   `json:"bin"`
 	Id          string `json:"uuid"`
+	Start       int64  `json:"start"`
+	End         int64  `json:"end"`
 	StartingBid int64  `json:"starting_bid"`
+	HighestBid  int64  `json:"highest_bid_amount"`
+	Seller      string `json:"auctioneer"`
+	Tier        string `json:"tier"`
 	ItemName    string `json:"item_name"`
 	ItemLore    string `json:"item_lore"`
 	ItemBytes   string `json:"item_bytes"`
 }
 
 func (auction *AuctionStruct) GetItem() (*utils.Item, error) {
-	data, err := base64.StdEncoding.DecodeString(auction.ItemBytes)
+	return decodeItem(auction.ItemBytes)
+}
+
+// LoreFacts reads the rarity line of the item lore
+func (auction *AuctionStruct) LoreFacts() text.LoreFacts {
+	return text.ParseLore(strings.Split(auction.ItemLore, "\n"))
+}
+
+func decodeItem(itemBytes string) (*utils.Item, error) {
+	items, err := utils.DecodeInventory(itemBytes)
 	if err != nil {
 		return nil, err
 	}
-	tag, err := nbt.Decode(bytes.NewReader(data))
-	if err != nil {
-		return nil, err
+	if len(items) == 0 {
+		return nil, fmt.Errorf("auction has no item")
 	}
-	return &utils.Item{Compound: tag.AsCompound().Get("i").AsList().GetValues()[0].(*nbt.Compound)}, nil
+	return &items[0], nil
 }
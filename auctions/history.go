@@ -0,0 +1,85 @@
+package auctions
+
+import (
+	"fmt"
+	"skyblock-pv-backend/internal"
+	"strings"
+	"time"
+)
+
+const addHistory = `
+	insert into auction_prices(sb_id, recorded_at, lowest, highest, median, mean)
+	select unnest($1::text[]), $2, unnest($3::bigint[]), unnest($4::bigint[]), unnest($5::bigint[]), unnest($6::float8[])
+	on conflict (sb_id, recorded_at) do nothing
+`
+
+const getHistory = `
+	select date_trunc($2, recorded_at) as bucket, min(lowest), max(highest), avg(median)::bigint, avg(mean)
+	from auction_prices
+	where sb_id = $1 and recorded_at >= $3 and recorded_at < $4
+	group by bucket
+	order by bucket
+`
+
+// HistoryIntervals maps the supported down-sampling intervals to postgres date_trunc units
+var HistoryIntervals = map[string]string{
+	"hourly": "hour",
+	"daily":  "day",
+	"weekly": "week",
+}
+
+type HistoryPoint struct {
+	Time    int64   `json:"time"`
+	Lowest  int64   `json:"lowest"`
+	Highest int64   `json:"highest"`
+	Median  int64   `json:"median"`
+	Mean    float64 `json:"mean"`
+}
+
+// storeHistory saves a snapshot, snapshots are recorded per hour so multiple instances don't create duplicates.
+// Only bare sb ids are recorded, fingerprint variants (containing ;) would multiply the table without retention.
+func storeHistory(ctx *internal.RouteContext, items map[string]ItemInfo, recordedAt time.Time) error {
+	ids := make([]string, 0, len(items))
+	lowest := make([]int64, 0, len(items))
+	highest := make([]int64, 0, len(items))
+	median := make([]int64, 0, len(items))
+	mean := make([]float64, 0, len(items))
+	for id, info := range items {
+		if strings.Contains(id, ";") {
+			continue
+		}
+		ids = append(ids, id)
+		lowest = append(lowest, info.Lowest)
+		highest = append(highest, info.Highest)
+		median = append(median, info.Median)
+		mean = append(mean, info.Mean)
+	}
+
+	_, err := ctx.Pool.Exec(*ctx.Context, addHistory, ids, recordedAt.Truncate(time.Hour), lowest, highest, median, mean)
+	return err
+}
+
+func GetHistory(ctx *internal.RouteContext, sbId string, from time.Time, to time.Time, interval string) ([]HistoryPoint, error) {
+	unit, ok := HistoryIntervals[interval]
+	if !ok {
+		return nil, fmt.Errorf("unknown interval %s", interval)
+	}
+
+	rows, err := ctx.Pool.Query(*ctx.Context, getHistory, sbId, unit, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := make([]HistoryPoint, 0)
+	for rows.Next() {
+		var point HistoryPoint
+		var bucket time.Time
+		if err := rows.Scan(&bucket, &point.Lowest, &point.Highest, &point.Median, &point.Mean); err != nil {
+			return nil, err
+		}
+		point.Time = bucket.UnixMilli()
+		points = append(points, point)
+	}
+	return points, rows.Err()
+}
@@ -0,0 +1,102 @@
+package auctions
+
+import (
+	"encoding/json"
+	"skyblock-pv-backend/internal"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// the index is reloaded from the cache after this, as another instance might have updated it
+const priceIndexReloadInterval = 5 * time.Minute
+
+var currentPriceIndex atomic.Pointer[PriceIndex]
+
+// PriceIndex is the parsed auction price snapshot, it is immutable and replaced after every update
+type PriceIndex struct {
+	raw      string
+	items    map[string]ItemInfo
+	ids      []string
+	loadedAt time.Time
+}
+
+func newPriceIndex(raw string, items map[string]ItemInfo) *PriceIndex {
+	ids := make([]string, 0, len(items))
+	for id := range items {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return &PriceIndex{raw, items, ids, time.Now()}
+}
+
+func setPriceIndex(raw string, items map[string]ItemInfo) {
+	currentPriceIndex.Store(newPriceIndex(raw, items))
+}
+
+func GetPriceIndex(ctx *internal.RouteContext) (*PriceIndex, error) {
+	index := currentPriceIndex.Load()
+	if index != nil && time.Since(index.loadedAt) < priceIndexReloadInterval {
+		return index, nil
+	}
+
+	raw, err := GetCachedAuctions(ctx)
+	if err != nil {
+		if index != nil {
+			return index, nil
+		}
+		return nil, err
+	}
+	if index != nil && index.raw == *raw {
+		index = &PriceIndex{index.raw, index.items, index.ids, time.Now()}
+		currentPriceIndex.Store(index)
+		return index, nil
+	}
+
+	items := make(map[string]ItemInfo)
+	if err := json.Unmarshal([]byte(*raw), &items); err != nil {
+		return nil, err
+	}
+	index = newPriceIndex(*raw, items)
+	currentPriceIndex.Store(index)
+	return index, nil
+}
+
+// Raw returns the snapshot as it is stored in the cache
+func (index *PriceIndex) Raw() string {
+	return index.raw
+}
+
+func (index *PriceIndex) Get(id string) (ItemInfo, bool) {
+	info, ok := index.items[id]
+	return info, ok
+}
+
+func (index *PriceIndex) Select(ids []string) map[string]ItemInfo {
+	result := make(map[string]ItemInfo, len(ids))
+	for _, id := range ids {
+		if info, ok := index.items[id]; ok {
+			result[id] = info
+		}
+	}
+	return result
+}
+
+func (index *PriceIndex) WithPrefix(prefix string) map[string]ItemInfo {
+	result := make(map[string]ItemInfo)
+	start, _ := slices.BinarySearch(index.ids, prefix)
+	for _, id := range index.ids[start:] {
+		if !strings.HasPrefix(id, prefix) {
+			break
+		}
+		result[id] = index.items[id]
+	}
+	return result
+}
+
+// Price returns the lowest bin of the item, this makes the index usable as a networth.PriceSource
+func (index *PriceIndex) Price(id string) (int64, bool) {
+	info, ok := index.items[id]
+	return info.Lowest, ok
+}
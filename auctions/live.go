@@ -0,0 +1,211 @@
+package auctions
+
+import (
+	"encoding/json"
+	"fmt"
+	"skyblock-pv-backend/internal"
+	"skyblock-pv-backend/utils"
+	"strings"
+	"sync"
+	"time"
+)
+
+// pages fetched per incremental refresh before waiting for the next full refresh to catch up
+const maxIncrementalPages = 10
+
+// liveAuctions are the currently active auctions, replaced by a full refresh and updated incrementally in between.
+// The items are decoded when an auction is first seen so incremental refreshes only decode the new auctions.
+type liveAuctions struct {
+	mutex       sync.RWMutex
+	auctions    map[string]indexedAuction
+	lastUpdated int64
+}
+
+var live = &liveAuctions{auctions: map[string]indexedAuction{}}
+
+func (live *liveAuctions) replace(auctions []AuctionStruct, fingerprinter *utils.Fingerprinter, lastUpdated int64) {
+	indexed := make(map[string]indexedAuction, len(auctions))
+	for _, auction := range auctions {
+		indexed[auction.Id] = indexAuction(auction, fingerprinter)
+	}
+
+	live.mutex.Lock()
+	defer live.mutex.Unlock()
+	live.auctions = indexed
+	live.lastUpdated = lastUpdated
+}
+
+func (live *liveAuctions) snapshot() []indexedAuction {
+	live.mutex.RLock()
+	defer live.mutex.RUnlock()
+	auctions := make([]indexedAuction, 0, len(live.auctions))
+	for _, auction := range live.auctions {
+		auctions = append(auctions, auction)
+	}
+	return auctions
+}
+
+func (live *liveAuctions) getLastUpdated() int64 {
+	live.mutex.RLock()
+	defer live.mutex.RUnlock()
+	return live.lastUpdated
+}
+
+// LastUpdated returns when hypixel last updated the loaded auctions, false if none have been loaded yet
+func LastUpdated() (time.Time, bool) {
+	lastUpdated := live.getLastUpdated()
+	if lastUpdated == 0 {
+		return time.Time{}, false
+	}
+	return time.UnixMilli(lastUpdated), true
+}
+
+// add returns the amount of auctions that weren't known yet, known auctions keep their decoded item and only
+// update the bids
+func (live *liveAuctions) add(auctions []AuctionStruct, fingerprinter *utils.Fingerprinter) int {
+	live.mutex.Lock()
+	defer live.mutex.Unlock()
+	added := 0
+	for _, auction := range auctions {
+		known, ok := live.auctions[auction.Id]
+		if !ok {
+			added++
+		}
+		if ok && known.ItemBytes == auction.ItemBytes {
+			known.AuctionStruct = auction
+			live.auctions[auction.Id] = known
+		} else {
+			live.auctions[auction.Id] = indexAuction(auction, fingerprinter)
+		}
+	}
+	return added
+}
+
+// remove drops the given auctions and every auction that ended before now, returns the amount of removed auctions
+func (live *liveAuctions) remove(ids []string, now time.Time) int {
+	live.mutex.Lock()
+	defer live.mutex.Unlock()
+	removed := 0
+	for _, id := range ids {
+		if _, ok := live.auctions[id]; ok {
+			delete(live.auctions, id)
+			removed++
+		}
+	}
+	for id, auction := range live.auctions {
+		if auction.End != 0 && auction.End < now.UnixMilli() {
+			delete(live.auctions, id)
+			removed++
+		}
+	}
+	return removed
+}
+
+// Refresh applies the changes since the last snapshot, new auctions are read from the first pages
+// and ended auctions from /v2/skyblock/auctions_ended. Nothing happens if hypixel has no new snapshot.
+func Refresh(ctx *internal.RouteContext) error {
+	first, err := fetchPage(*ctx, 0, nil)
+	if err != nil {
+		return err
+	}
+	if first.LastUpdated == live.getLastUpdated() {
+		return nil
+	}
+
+	recorder := newRunRecorder(IncrementalRun)
+	err = refresh(ctx, first, recorder)
+	recorder.finish(err)
+	return err
+}
+
+func refresh(ctx *internal.RouteContext, first *AuctionRespond, recorder *runRecorder) error {
+	fingerprinter, err := newFingerprinter(ctx)
+	if err != nil {
+		return err
+	}
+	added := live.add(first.Auctions, fingerprinter)
+	recorder.update(func(stats *RunStats) {
+		stats.TotalPages = first.TotalPages
+		stats.Pages++
+	})
+	for page := 1; page < min(first.TotalPages, maxIncrementalPages); page++ {
+		if added == 0 {
+			break
+		}
+		data, err := fetchPageWithRetries(*ctx, page, nil, recorder)
+		if err != nil {
+			recorder.update(func(stats *RunStats) {
+				stats.FailedPages = append(stats.FailedPages, page)
+			})
+			return err
+		}
+		added = live.add(data.Auctions, fingerprinter)
+		recorder.update(func(stats *RunStats) {
+			stats.Pages++
+		})
+	}
+
+	ended, err := fetchEnded(*ctx)
+	if err != nil {
+		return err
+	}
+	endedIds := make([]string, len(ended.Auctions))
+	for i, auction := range ended.Auctions {
+		endedIds[i] = auction.AuctionId
+	}
+	live.remove(endedIds, time.Now())
+	if err := storeSales(ctx, ended.Auctions); err != nil {
+		ctx.Logger.Error("Failed to store auction sales", "err", err)
+	}
+
+	live.mutex.Lock()
+	live.lastUpdated = first.LastUpdated
+	live.mutex.Unlock()
+
+	auctions := live.snapshot()
+	items, err := publish(ctx, auctions, first.LastUpdated)
+	recorder.update(func(stats *RunStats) {
+		stats.Auctions = len(auctions)
+		if items != nil {
+			stats.Items = len(*items)
+		}
+	})
+	return err
+}
+
+func fetchEnded(ctx internal.RouteContext) (*EndedAuctionsRespond, error) {
+	res, err := internal.GetFromHypixel(ctx, "/v2/skyblock/auctions_ended", false)
+	if err != nil {
+		return nil, err
+	} else if res == nil {
+		return nil, fmt.Errorf("no data received from hypixel")
+	}
+
+	var ended EndedAuctionsRespond
+	if err := json.NewDecoder(strings.NewReader(*res)).Decode(&ended); err != nil {
+		return nil, err
+	}
+	return &ended, nil
+}
+
+type EndedAuctionsRespond struct {
+	Success     bool           `json:"success"`
+	LastUpdated int64          `json:"lastUpdated"`
+	Auctions    []EndedAuction `json:"auctions"`
+}
+
+type EndedAuction struct {
+	AuctionId     string `json:"auction_id"`
+	Seller        string `json:"seller"`
+	SellerProfile string `json:"seller_profile"`
+	Buyer         string `json:"buyer"`
+	BuyerProfile  string `json:"buyer_profile"`
+	Timestamp     int64  `json:"timestamp"`
+	Price         int64  `json:"price"`
+	Bin           bool   `json:"bin"`
+	ItemBytes     string `json:"item_bytes"`
+}
+
+func (auction *EndedAuction) GetItem() (*utils.Item, error) {
+	return decodeItem(auction.ItemBytes)
+}
@@ -0,0 +1,172 @@
+package auctions
+
+import (
+	"fmt"
+	"skyblock-pv-backend/internal"
+	"sync"
+	"time"
+)
+
+// sold prices are kept longer than the largest window so the windows can be changed without losing data
+const salesRetention = 30 * 24 * time.Hour
+
+// the sold statistics are recalculated at most this often, publish runs every minute
+const soldStatsInterval = 10 * time.Minute
+
+const addSales = `
+	insert into auction_sales(auction_id, sb_id, price, sold_at, bin)
+	select unnest($1::text[]), unnest($2::text[]), unnest($3::bigint[]), unnest($4::timestamptz[]), unnest($5::boolean[])
+	on conflict (auction_id) do nothing
+`
+
+const pruneSales = `delete from auction_sales where sold_at < $1`
+
+// sales outside of the tukey fences (1.5 times the interquartile range) are ignored for the estimate
+const getSoldStats = `
+	with windowed as (
+		select s.sb_id, w.name, s.price
+		from auction_sales s
+		join unnest($1::text[], $2::bigint[]) as w(name, seconds) on s.sold_at >= now() - w.seconds * interval '1 second'
+	),
+	quartiles as (
+		select sb_id, name, count(*) as volume,
+			percentile_disc(array[0.1, 0.25, 0.5, 0.75, 0.9]) within group (order by price) as percentiles
+		from windowed
+		group by sb_id, name
+	),
+	filtered as (
+		select w.sb_id, w.name, percentile_disc(0.5) within group (order by w.price) as estimate
+		from windowed w
+		join quartiles q using (sb_id, name)
+		where w.price between q.percentiles[2] - 1.5 * (q.percentiles[4] - q.percentiles[2])
+			and q.percentiles[4] + 1.5 * (q.percentiles[4] - q.percentiles[2])
+		group by w.sb_id, w.name
+	)
+	select q.sb_id, q.name, q.volume, q.percentiles, f.estimate
+	from quartiles q
+	join filtered f using (sb_id, name)
+`
+
+// SoldWindows are the rolling windows the sold statistics are calculated for
+var SoldWindows = map[string]time.Duration{
+	"1h":  time.Hour,
+	"24h": 24 * time.Hour,
+	"7d":  7 * 24 * time.Hour,
+}
+
+// SoldInfo describes the prices an item actually sold for, prices are per unit.
+// Estimate is the median after dropping outliers and is the best guess for the market value.
+type SoldInfo struct {
+	Volume   int64 `json:"volume"`
+	Median   int64 `json:"median"`
+	P10      int64 `json:"p10"`
+	P25      int64 `json:"p25"`
+	P75      int64 `json:"p75"`
+	P90      int64 `json:"p90"`
+	Estimate int64 `json:"estimate"`
+}
+
+type soldStatsCache struct {
+	mutex        sync.Mutex
+	stats        map[string]map[string]SoldInfo
+	calculatedAt time.Time
+}
+
+var soldStats = &soldStatsCache{}
+
+// storeSales saves the sold price of ended auctions, auctions are only stored once so overlapping polls are fine
+func storeSales(ctx *internal.RouteContext, ended []EndedAuction) error {
+	ids := make([]string, 0, len(ended))
+	sbIds := make([]string, 0, len(ended))
+	prices := make([]int64, 0, len(ended))
+	soldAt := make([]time.Time, 0, len(ended))
+	bin := make([]bool, 0, len(ended))
+	for _, auction := range ended {
+		item, err := auction.GetItem()
+		if err != nil {
+			continue
+		}
+		sbId := item.GetSbId()
+		if sbId == nil {
+			continue
+		}
+		ids = append(ids, auction.AuctionId)
+		sbIds = append(sbIds, *sbId)
+		prices = append(prices, auction.Price/int64(max(item.Count(), 1)))
+		soldAt = append(soldAt, time.UnixMilli(auction.Timestamp))
+		bin = append(bin, auction.Bin)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	_, err := ctx.Pool.Exec(*ctx.Context, addSales, ids, sbIds, prices, soldAt, bin)
+	return err
+}
+
+func pruneOldSales(ctx *internal.RouteContext) error {
+	_, err := ctx.Pool.Exec(*ctx.Context, pruneSales, time.Now().Add(-salesRetention))
+	return err
+}
+
+// get returns the sold statistics per item and window, they are recalculated every soldStatsInterval
+func (cache *soldStatsCache) get(ctx *internal.RouteContext) (map[string]map[string]SoldInfo, error) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+	if cache.stats != nil && time.Since(cache.calculatedAt) < soldStatsInterval {
+		return cache.stats, nil
+	}
+
+	stats, err := calculateSoldStats(ctx)
+	if err != nil {
+		return cache.stats, err
+	}
+	cache.stats = stats
+	cache.calculatedAt = time.Now()
+	return stats, nil
+}
+
+func calculateSoldStats(ctx *internal.RouteContext) (map[string]map[string]SoldInfo, error) {
+	names := make([]string, 0, len(SoldWindows))
+	seconds := make([]int64, 0, len(SoldWindows))
+	for name, length := range SoldWindows {
+		names = append(names, name)
+		seconds = append(seconds, int64(length.Seconds()))
+	}
+
+	rows, err := ctx.Pool.Query(*ctx.Context, getSoldStats, names, seconds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make(map[string]map[string]SoldInfo)
+	for rows.Next() {
+		var sbId, window string
+		var info SoldInfo
+		var percentiles []int64
+		if err := rows.Scan(&sbId, &window, &info.Volume, &percentiles, &info.Estimate); err != nil {
+			return nil, err
+		}
+		if len(percentiles) != 5 {
+			return nil, fmt.Errorf("expected 5 percentiles for %s, got %d", sbId, len(percentiles))
+		}
+		info.P10, info.P25, info.Median, info.P75, info.P90 = percentiles[0], percentiles[1], percentiles[2], percentiles[3], percentiles[4]
+
+		if stats[sbId] == nil {
+			stats[sbId] = make(map[string]SoldInfo, len(SoldWindows))
+		}
+		stats[sbId][window] = info
+	}
+	return stats, rows.Err()
+}
+
+// addSoldStats attaches the sold statistics to the items with active listings
+func addSoldStats(items map[string]ItemInfo, stats map[string]map[string]SoldInfo) {
+	for id, sold := range stats {
+		if info, ok := items[id]; ok {
+			info.Sold = sold
+			items[id] = info
+		}
+	}
+}
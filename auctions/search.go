@@ -0,0 +1,396 @@
+package auctions
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"skyblock-pv-backend/utils"
+	"skyblock-pv-backend/utils/nbt"
+	"skyblock-pv-backend/utils/text"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	SortPriceAscending  = "price_asc"
+	SortPriceDescending = "price_desc"
+	SortEndingSoon      = "ending_soon"
+	SortNewest          = "newest"
+)
+
+const (
+	DefaultSearchLimit = 20
+	MaxSearchLimit     = 100
+)
+
+var (
+	ErrSearchUnavailable = errors.New("no auctions have been loaded yet")
+	ErrInvalidCursor     = errors.New("invalid cursor")
+)
+
+var currentSearchIndex atomic.Pointer[searchIndex]
+
+// indexedAuction is an auction with the values searched for, the item is decoded once when the auction is first seen
+type indexedAuction struct {
+	AuctionStruct
+	// decoded is false if the item bytes are invalid, these auctions are left out of the index
+	decoded bool
+	sbId    string
+	// fingerprint is empty if it is the same as the sb id
+	fingerprint string
+	// name is lowercase without formatting codes
+	name       string
+	rarity     string
+	count      int
+	attributes nbt.WrappedTag
+}
+
+// price is the starting bid of bins and the current bid of auctions
+func (auction *indexedAuction) price() int64 {
+	if auction.Bin {
+		return auction.StartingBid
+	}
+	return max(auction.StartingBid, auction.HighestBid)
+}
+
+// searchIndex is built from the live auctions after every refresh, it is immutable and replaced as a whole
+type searchIndex struct {
+	auctions    []indexedAuction
+	bySbId      map[string][]int
+	lastUpdated int64
+}
+
+// indexAuction decodes the item of an auction
+func indexAuction(auction AuctionStruct, fingerprinter *utils.Fingerprinter) indexedAuction {
+	indexed := indexedAuction{AuctionStruct: auction}
+	item, err := auction.GetItem()
+	if err != nil {
+		return indexed
+	}
+
+	indexed.decoded = true
+	indexed.name = strings.ToLower(text.Strip(auction.ItemName))
+	indexed.rarity = auction.LoreFacts().Rarity
+	indexed.count = max(item.Count(), 1)
+	indexed.attributes = item.Attributes()
+	if indexed.rarity == "" {
+		indexed.rarity = strings.ReplaceAll(auction.Tier, "_", " ")
+	}
+	if sbId := item.GetSbId(); sbId != nil {
+		indexed.sbId = *sbId
+		if fingerprint := fingerprinter.Fingerprint(*item); fingerprint != nil && *fingerprint != *sbId {
+			indexed.fingerprint = *fingerprint
+		}
+	}
+	return indexed
+}
+
+// newSearchIndex indexes the decoded auctions, auctions with items that can't be decoded are left out
+func newSearchIndex(auctions []indexedAuction, lastUpdated int64) *searchIndex {
+	index := &searchIndex{
+		auctions:    make([]indexedAuction, 0, len(auctions)),
+		bySbId:      make(map[string][]int),
+		lastUpdated: lastUpdated,
+	}
+	for _, auction := range auctions {
+		if !auction.decoded {
+			continue
+		}
+		index.bySbId[auction.sbId] = append(index.bySbId[auction.sbId], len(index.auctions))
+		index.auctions = append(index.auctions, auction)
+	}
+	return index
+}
+
+// AttributeFilter compares an attribute of the item, the path is relative to the custom data of the item
+type AttributeFilter struct {
+	Path *nbt.Path
+	// Operator is one of = != > >= < <=, an empty operator only checks that the attribute exists
+	Operator string
+	Value    string
+}
+
+var attributeOperators = []string{"!=", ">=", "<=", "=", ">", "<"}
+
+// ParseAttributeFilter reads filters such as modifier=heroic, enchantments.ultimate_wise>=4 or gems
+func ParseAttributeFilter(filter string) (*AttributeFilter, error) {
+	// operators inside quoted keys are part of the key
+	quoted := false
+	for i := 0; i < len(filter); i++ {
+		switch {
+		case filter[i] == '"':
+			quoted = !quoted
+		case filter[i] == '\\' && quoted:
+			i++
+		case !quoted:
+			for _, operator := range attributeOperators {
+				if strings.HasPrefix(filter[i:], operator) {
+					path, err := nbt.CompilePath(filter[:i])
+					if err != nil {
+						return nil, err
+					}
+					return &AttributeFilter{path, operator, filter[i+len(operator):]}, nil
+				}
+			}
+		}
+	}
+	path, err := nbt.CompilePath(filter)
+	if err != nil {
+		return nil, err
+	}
+	return &AttributeFilter{Path: path}, nil
+}
+
+// matches is true if any tag the path selects fulfills the filter, numbers are compared by value and strings
+// can only be compared with = and !=
+func (filter *AttributeFilter) matches(attributes nbt.WrappedTag) bool {
+	for _, match := range filter.Path.All(attributes) {
+		if filter.Operator == "" || filter.compare(match.Tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func (filter *AttributeFilter) compare(tag nbt.WrappedTag) bool {
+	var value float64
+	if integer, ok := tag.AsInteger(); ok {
+		value = float64(integer)
+	} else if tag.Type() == nbt.TAG_FLOAT {
+		value = float64(tag.AsFloat())
+	} else if tag.Type() == nbt.TAG_DOUBLE {
+		value = tag.AsDouble()
+	} else if tag.Type() == nbt.TAG_STRING {
+		switch filter.Operator {
+		case "=":
+			return strings.EqualFold(tag.AsString(), filter.Value)
+		case "!=":
+			return !strings.EqualFold(tag.AsString(), filter.Value)
+		}
+		return false
+	} else {
+		return false
+	}
+
+	expected, err := strconv.ParseFloat(filter.Value, 64)
+	if err != nil {
+		return false
+	}
+	switch filter.Operator {
+	case "=":
+		return value == expected
+	case "!=":
+		return value != expected
+	case ">":
+		return value > expected
+	case ">=":
+		return value >= expected
+	case "<":
+		return value < expected
+	case "<=":
+		return value <= expected
+	}
+	return false
+}
+
+// SearchQuery filters the live auctions, zero values don't filter
+type SearchQuery struct {
+	// SbId matches the sb id or the fingerprint of the item
+	SbId string
+	// Name is a case-insensitive substring of the item name
+	Name   string
+	Rarity string
+	Bin    *bool
+	// MinPrice and MaxPrice are inclusive, the price of auctions is their current bid
+	MinPrice int64
+	MaxPrice int64
+	// Seller is the uuid of the seller, with or without dashes
+	Seller     string
+	Attributes []AttributeFilter
+	Sort       string
+	// Cursor is the Next of the previous page
+	Cursor string
+	Limit  int
+}
+
+type SearchResult struct {
+	Auctions []SearchedAuction `json:"auctions"`
+	// Next is the cursor of the next page, missing on the last page
+	Next        string `json:"next,omitempty"`
+	LastUpdated int64  `json:"last_updated"`
+}
+
+type SearchedAuction struct {
+	Id          string `json:"uuid"`
+	Seller      string `json:"seller"`
+	SbId        string `json:"sb_id,omitempty"`
+	Fingerprint string `json:"fingerprint,omitempty"`
+	Name        string `json:"item_name"`
+	Rarity      string `json:"rarity,omitempty"`
+	Count       int    `json:"count"`
+	Bin         bool   `json:"bin"`
+	Price       int64  `json:"price"`
+	StartingBid int64  `json:"starting_bid"`
+	HighestBid  int64  `json:"highest_bid"`
+	Start       int64  `json:"start"`
+	End         int64  `json:"end"`
+	ItemBytes   string `json:"item_bytes"`
+}
+
+func (query *SearchQuery) matches(auction *indexedAuction) bool {
+	if query.SbId != "" && auction.sbId != query.SbId && auction.fingerprint != query.SbId {
+		return false
+	}
+	if query.Name != "" && !strings.Contains(auction.name, strings.ToLower(query.Name)) {
+		return false
+	}
+	if query.Rarity != "" && !strings.EqualFold(auction.rarity, query.Rarity) {
+		return false
+	}
+	if query.Bin != nil && auction.Bin != *query.Bin {
+		return false
+	}
+	if price := auction.price(); (query.MinPrice > 0 && price < query.MinPrice) || (query.MaxPrice > 0 && price > query.MaxPrice) {
+		return false
+	}
+	if query.Seller != "" && normalizeUuid(auction.Seller) != normalizeUuid(query.Seller) {
+		return false
+	}
+	for _, filter := range query.Attributes {
+		if !filter.matches(auction.attributes) {
+			return false
+		}
+	}
+	return true
+}
+
+func normalizeUuid(uuid string) string {
+	return strings.ToLower(strings.ReplaceAll(uuid, "-", ""))
+}
+
+// sortKey orders the auctions ascending, the auction id breaks ties so the order is stable between refreshes
+func sortKey(auction *indexedAuction, sort string) int64 {
+	switch sort {
+	case SortPriceDescending:
+		return -auction.price()
+	case SortEndingSoon:
+		return auction.End
+	case SortNewest:
+		return -auction.Start
+	}
+	return auction.price()
+}
+
+func encodeCursor(key int64, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%s", key, id)))
+}
+
+func decodeCursor(cursor string) (int64, string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, "", ErrInvalidCursor
+	}
+	value, id, ok := strings.Cut(string(data), ":")
+	if !ok {
+		return 0, "", ErrInvalidCursor
+	}
+	key, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, "", ErrInvalidCursor
+	}
+	return key, id, nil
+}
+
+// Search returns a page of the live auctions matching the query. Pages continue after the last auction of the
+// previous page, so auctions that were added or removed in between don't shift the pages.
+func Search(query SearchQuery) (*SearchResult, error) {
+	index := currentSearchIndex.Load()
+	if index == nil {
+		return nil, ErrSearchUnavailable
+	}
+	switch query.Sort {
+	case "":
+		query.Sort = SortPriceAscending
+	case SortPriceAscending, SortPriceDescending, SortEndingSoon, SortNewest:
+	default:
+		return nil, fmt.Errorf("unknown sort %s", query.Sort)
+	}
+	if query.Limit <= 0 {
+		query.Limit = DefaultSearchLimit
+	}
+	query.Limit = min(query.Limit, MaxSearchLimit)
+
+	hasCursor := query.Cursor != ""
+	var cursorKey int64
+	var cursorId string
+	if hasCursor {
+		var err error
+		if cursorKey, cursorId, err = decodeCursor(query.Cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	type candidate struct {
+		key     int64
+		auction *indexedAuction
+	}
+	candidates := make([]candidate, 0)
+	visit := func(auction *indexedAuction) {
+		key := sortKey(auction, query.Sort)
+		if hasCursor && (key < cursorKey || (key == cursorKey && auction.Id <= cursorId)) {
+			return
+		}
+		if query.matches(auction) {
+			candidates = append(candidates, candidate{key, auction})
+		}
+	}
+	if query.SbId != "" {
+		// fingerprints start with the sb id followed by ;
+		sbId, _, _ := strings.Cut(query.SbId, ";")
+		for _, i := range index.bySbId[sbId] {
+			visit(&index.auctions[i])
+		}
+	} else {
+		for i := range index.auctions {
+			visit(&index.auctions[i])
+		}
+	}
+
+	slices.SortFunc(candidates, func(a, b candidate) int {
+		if a.key != b.key {
+			if a.key < b.key {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.auction.Id, b.auction.Id)
+	})
+
+	result := &SearchResult{Auctions: make([]SearchedAuction, 0, min(len(candidates), query.Limit)), LastUpdated: index.lastUpdated}
+	for _, candidate := range candidates[:min(len(candidates), query.Limit)] {
+		auction := candidate.auction
+		result.Auctions = append(result.Auctions, SearchedAuction{
+			Id:          auction.Id,
+			Seller:      auction.Seller,
+			SbId:        auction.sbId,
+			Fingerprint: auction.fingerprint,
+			Name:        auction.ItemName,
+			Rarity:      auction.rarity,
+			Count:       auction.count,
+			Bin:         auction.Bin,
+			Price:       auction.price(),
+			StartingBid: auction.StartingBid,
+			HighestBid:  auction.HighestBid,
+			Start:       auction.Start,
+			End:         auction.End,
+			ItemBytes:   auction.ItemBytes,
+		})
+	}
+	if len(candidates) > query.Limit {
+		last := candidates[query.Limit-1]
+		result.Next = encodeCursor(last.key, last.auction.Id)
+	}
+	return result, nil
+}
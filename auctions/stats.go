@@ -0,0 +1,117 @@
+package auctions
+
+import (
+	"skyblock-pv-backend/internal/metrics"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	FullRun        = "full"
+	IncrementalRun = "incremental"
+)
+
+var (
+	runDuration = metrics.NewHistogram(
+		"skyblock_auction_refresh_duration_seconds",
+		"Duration of auction refreshes by kind (full or incremental) and whether the snapshot was accepted",
+		[]float64{0.5, 1, 2.5, 5, 10, 20, 30, 60, 120, 300},
+		"kind", "accepted",
+	)
+	runPages = metrics.NewGauge(
+		"skyblock_auction_refresh_pages",
+		"Pages fetched by the last auction refresh of every kind",
+		"kind",
+	)
+	runFailedPages = metrics.NewGauge(
+		"skyblock_auction_refresh_failed_pages",
+		"Pages that failed in the last auction refresh of every kind",
+		"kind",
+	)
+	runAuctions = metrics.NewGauge(
+		"skyblock_auction_refresh_auctions",
+		"Auctions loaded by the last auction refresh of every kind",
+		"kind",
+	)
+	runItems = metrics.NewGauge(
+		"skyblock_auction_refresh_items",
+		"Items priced by the last auction refresh of every kind",
+		"kind",
+	)
+)
+
+// RunStats describe a single refresh of the auction data
+type RunStats struct {
+	Kind        string `json:"kind"`
+	StartedAt   int64  `json:"started_at"`
+	DurationMs  int64  `json:"duration_ms"`
+	TotalPages  int    `json:"total_pages"`
+	Pages       int    `json:"pages"`
+	FailedPages []int  `json:"failed_pages"`
+	Retries     int    `json:"retries"`
+	Auctions    int    `json:"auctions"`
+	Items       int    `json:"items"`
+	Accepted    bool   `json:"accepted"`
+	Error       string `json:"error,omitempty"`
+}
+
+// runRecorder collects the stats of a run, pages are fetched concurrently
+type runRecorder struct {
+	mutex   sync.Mutex
+	stats   RunStats
+	started time.Time
+}
+
+var lastRuns = struct {
+	sync.RWMutex
+	runs map[string]RunStats
+}{runs: map[string]RunStats{}}
+
+func newRunRecorder(kind string) *runRecorder {
+	now := time.Now()
+	return &runRecorder{
+		stats:   RunStats{Kind: kind, StartedAt: now.UnixMilli(), FailedPages: make([]int, 0)},
+		started: now,
+	}
+}
+
+func (recorder *runRecorder) update(update func(stats *RunStats)) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	update(&recorder.stats)
+}
+
+// finish records the run, err is the reason the snapshot was not accepted
+func (recorder *runRecorder) finish(err error) {
+	recorder.mutex.Lock()
+	stats := recorder.stats
+	recorder.mutex.Unlock()
+
+	stats.DurationMs = time.Since(recorder.started).Milliseconds()
+	stats.Accepted = err == nil
+	if err != nil {
+		stats.Error = err.Error()
+	}
+
+	runDuration.Observe(time.Since(recorder.started).Seconds(), stats.Kind, strconv.FormatBool(stats.Accepted))
+	runPages.Set(float64(stats.Pages), stats.Kind)
+	runFailedPages.Set(float64(len(stats.FailedPages)), stats.Kind)
+	runAuctions.Set(float64(stats.Auctions), stats.Kind)
+	runItems.Set(float64(stats.Items), stats.Kind)
+
+	lastRuns.Lock()
+	defer lastRuns.Unlock()
+	lastRuns.runs[stats.Kind] = stats
+}
+
+// LastRuns returns the last run of every kind
+func LastRuns() map[string]RunStats {
+	lastRuns.RLock()
+	defer lastRuns.RUnlock()
+	runs := make(map[string]RunStats, len(lastRuns.runs))
+	for kind, run := range lastRuns.runs {
+		runs[kind] = run
+	}
+	return runs
+}
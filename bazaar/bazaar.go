@@ -0,0 +1,216 @@
+package bazaar
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"skyblock-pv-backend/internal"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const CacheVersion = 1
+
+func withCacheVersion(str string) string { return fmt.Sprintf("%s_%d", str, CacheVersion) }
+
+// the snapshot is kept longer than the update interval so a few failed updates don't drop the prices
+const cacheDuration = 30 * time.Minute
+
+// the index is reloaded from the cache after this, as another instance might have updated it
+const indexReloadInterval = time.Minute
+
+// orders of the summary that are kept for every product
+const maxSummaryOrders = 5
+
+type bazaarRespond struct {
+	Success     bool                      `json:"success"`
+	LastUpdated int64                     `json:"lastUpdated"`
+	Products    map[string]productRespond `json:"products"`
+}
+
+type productRespond struct {
+	ProductId   string         `json:"product_id"`
+	SellSummary []orderRespond `json:"sell_summary"`
+	BuySummary  []orderRespond `json:"buy_summary"`
+	QuickStatus struct {
+		SellPrice      float64 `json:"sellPrice"`
+		SellVolume     int64   `json:"sellVolume"`
+		SellMovingWeek int64   `json:"sellMovingWeek"`
+		SellOrders     int     `json:"sellOrders"`
+		BuyPrice       float64 `json:"buyPrice"`
+		BuyVolume      int64   `json:"buyVolume"`
+		BuyMovingWeek  int64   `json:"buyMovingWeek"`
+		BuyOrders      int     `json:"buyOrders"`
+	} `json:"quick_status"`
+}
+
+type orderRespond struct {
+	Amount       int64   `json:"amount"`
+	PricePerUnit float64 `json:"pricePerUnit"`
+	Orders       int     `json:"orders"`
+}
+
+type Order struct {
+	Amount       int64   `json:"amount"`
+	PricePerUnit float64 `json:"price_per_unit"`
+	Orders       int     `json:"orders"`
+}
+
+// Product is the summary of a bazaar product. Buy is what buying instantly costs (the cheapest sell offer),
+// Sell is what selling instantly yields (the highest buy order), as in the hypixel api.
+type Product struct {
+	ProductId      string  `json:"product_id"`
+	BuyPrice       float64 `json:"buy_price"`
+	SellPrice      float64 `json:"sell_price"`
+	BuyVolume      int64   `json:"buy_volume"`
+	SellVolume     int64   `json:"sell_volume"`
+	BuyMovingWeek  int64   `json:"buy_moving_week"`
+	SellMovingWeek int64   `json:"sell_moving_week"`
+	BuyOrders      int     `json:"buy_orders"`
+	SellOrders     int     `json:"sell_orders"`
+	// BuySummary are the cheapest sell offers, SellSummary the highest buy orders
+	BuySummary  []Order `json:"buy_summary"`
+	SellSummary []Order `json:"sell_summary"`
+}
+
+type Snapshot struct {
+	LastUpdated int64 `json:"last_updated"`
+	// Products are keyed by sb id, see SbId
+	Products map[string]Product `json:"products"`
+}
+
+var enchantmentProduct = regexp.MustCompile(`^ENCHANTMENT_(.+)_(\d+)$`)
+
+// SbId maps a product id to the id used for auction prices, enchantments are enchantment:name:level
+// (e.g. ENCHANTMENT_ULTIMATE_WISE_5 is enchantment:ultimate_wise:5), every other product uses its id
+func SbId(productId string) string {
+	if match := enchantmentProduct.FindStringSubmatch(productId); match != nil {
+		return fmt.Sprintf("enchantment:%s:%s", strings.ToLower(match[1]), match[2])
+	}
+	return productId
+}
+
+var currentIndex atomic.Pointer[Index]
+
+// Index is the parsed bazaar snapshot, it is immutable and replaced after every update
+type Index struct {
+	raw      string
+	snapshot Snapshot
+	loadedAt time.Time
+}
+
+func (index *Index) LastUpdated() int64 {
+	return index.snapshot.LastUpdated
+}
+
+func (index *Index) Products() map[string]Product {
+	return index.snapshot.Products
+}
+
+func (index *Index) Get(sbId string) (Product, bool) {
+	product, ok := index.snapshot.Products[sbId]
+	return product, ok
+}
+
+// Price returns the instant buy price of the product, this makes the index usable as a networth.PriceSource
+func (index *Index) Price(sbId string) (int64, bool) {
+	product, ok := index.snapshot.Products[sbId]
+	if !ok || product.BuyPrice <= 0 {
+		return 0, false
+	}
+	return int64(product.BuyPrice), true
+}
+
+// Fetch downloads the bazaar, caches the snapshot and records the prices of the current hour
+func Fetch(ctx *internal.RouteContext) error {
+	res, err := internal.GetFromHypixel(*ctx, "/v2/skyblock/bazaar", false)
+	if err != nil {
+		return err
+	} else if res == nil {
+		return fmt.Errorf("no data received from hypixel")
+	}
+
+	snapshot, err := parseSnapshot(*res)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	_ = ctx.AddToCache(withCacheVersion("bazaar"), "cached", data, cacheDuration)
+	currentIndex.Store(&Index{string(data), snapshot, time.Now()})
+
+	if err := storeHistory(ctx, snapshot, time.Now()); err != nil {
+		ctx.Logger.Error("Failed to store bazaar history", "err", err)
+	}
+	return nil
+}
+
+// parseSnapshot reads the /v2/skyblock/bazaar response
+func parseSnapshot(data string) (Snapshot, error) {
+	var respond bazaarRespond
+	if err := json.Unmarshal([]byte(data), &respond); err != nil {
+		return Snapshot{}, err
+	}
+	if !respond.Success {
+		return Snapshot{}, fmt.Errorf("hypixel returned an unsuccessful bazaar response")
+	}
+
+	snapshot := Snapshot{LastUpdated: respond.LastUpdated, Products: make(map[string]Product, len(respond.Products))}
+	for productId, product := range respond.Products {
+		status := product.QuickStatus
+		snapshot.Products[SbId(productId)] = Product{
+			ProductId:      productId,
+			BuyPrice:       status.BuyPrice,
+			SellPrice:      status.SellPrice,
+			BuyVolume:      status.BuyVolume,
+			SellVolume:     status.SellVolume,
+			BuyMovingWeek:  status.BuyMovingWeek,
+			SellMovingWeek: status.SellMovingWeek,
+			BuyOrders:      status.BuyOrders,
+			SellOrders:     status.SellOrders,
+			BuySummary:     summary(product.BuySummary),
+			SellSummary:    summary(product.SellSummary),
+		}
+	}
+	return snapshot, nil
+}
+
+func summary(orders []orderRespond) []Order {
+	summary := make([]Order, 0, min(len(orders), maxSummaryOrders))
+	for _, order := range orders[:min(len(orders), maxSummaryOrders)] {
+		summary = append(summary, Order(order))
+	}
+	return summary
+}
+
+func GetIndex(ctx *internal.RouteContext) (*Index, error) {
+	index := currentIndex.Load()
+	if index != nil && time.Since(index.loadedAt) < indexReloadInterval {
+		return index, nil
+	}
+
+	raw, err := ctx.GetFromCache(nil, withCacheVersion("bazaar"), "cached")
+	if err != nil {
+		if index != nil {
+			return index, nil
+		}
+		return nil, err
+	}
+	if index != nil && index.raw == raw {
+		index = &Index{index.raw, index.snapshot, time.Now()}
+		currentIndex.Store(index)
+		return index, nil
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal([]byte(raw), &snapshot); err != nil {
+		return nil, err
+	}
+	index = &Index{raw, snapshot, time.Now()}
+	currentIndex.Store(index)
+	return index, nil
+}
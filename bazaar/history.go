@@ -0,0 +1,108 @@
+package bazaar
+
+import (
+	"fmt"
+	"skyblock-pv-backend/internal"
+	"sync"
+	"time"
+)
+
+const addHistory = `
+	insert into bazaar_prices(product_id, recorded_at, buy_price, sell_price, buy_volume, sell_volume, buy_moving_week, sell_moving_week)
+	select unnest($1::text[]), $2, unnest($3::float8[]), unnest($4::float8[]), unnest($5::bigint[]), unnest($6::bigint[]), unnest($7::bigint[]), unnest($8::bigint[])
+	on conflict (product_id, recorded_at) do nothing
+`
+
+const getHistory = `
+	select date_trunc($2, recorded_at) as bucket, avg(buy_price), avg(sell_price), avg(buy_volume)::bigint, avg(sell_volume)::bigint
+	from bazaar_prices
+	where product_id = $1 and recorded_at >= $3 and recorded_at < $4
+	group by bucket
+	order by bucket
+`
+
+// HistoryIntervals maps the supported down-sampling intervals to postgres date_trunc units
+var HistoryIntervals = map[string]string{
+	"hourly": "hour",
+	"daily":  "day",
+	"weekly": "week",
+}
+
+type HistoryPoint struct {
+	Time       int64   `json:"time"`
+	BuyPrice   float64 `json:"buy_price"`
+	SellPrice  float64 `json:"sell_price"`
+	BuyVolume  int64   `json:"buy_volume"`
+	SellVolume int64   `json:"sell_volume"`
+}
+
+// the bazaar is fetched every minute but recorded once per hour
+var lastRecorded = struct {
+	sync.Mutex
+	hour time.Time
+}{}
+
+// storeHistory saves the first snapshot of every hour, products are stored by their product id
+func storeHistory(ctx *internal.RouteContext, snapshot Snapshot, recordedAt time.Time) error {
+	hour := recordedAt.Truncate(time.Hour)
+	lastRecorded.Lock()
+	defer lastRecorded.Unlock()
+	if lastRecorded.hour.Equal(hour) {
+		return nil
+	}
+
+	ids := make([]string, 0, len(snapshot.Products))
+	buyPrice := make([]float64, 0, len(snapshot.Products))
+	sellPrice := make([]float64, 0, len(snapshot.Products))
+	buyVolume := make([]int64, 0, len(snapshot.Products))
+	sellVolume := make([]int64, 0, len(snapshot.Products))
+	buyMovingWeek := make([]int64, 0, len(snapshot.Products))
+	sellMovingWeek := make([]int64, 0, len(snapshot.Products))
+	for _, product := range snapshot.Products {
+		ids = append(ids, product.ProductId)
+		buyPrice = append(buyPrice, product.BuyPrice)
+		sellPrice = append(sellPrice, product.SellPrice)
+		buyVolume = append(buyVolume, product.BuyVolume)
+		sellVolume = append(sellVolume, product.SellVolume)
+		buyMovingWeek = append(buyMovingWeek, product.BuyMovingWeek)
+		sellMovingWeek = append(sellMovingWeek, product.SellMovingWeek)
+	}
+
+	_, err := ctx.Pool.Exec(*ctx.Context, addHistory, ids, hour, buyPrice, sellPrice, buyVolume, sellVolume, buyMovingWeek, sellMovingWeek)
+	if err == nil {
+		lastRecorded.hour = hour
+	}
+	return err
+}
+
+// GetHistory returns the prices of a product, the id is the product id or the sb id
+func GetHistory(ctx *internal.RouteContext, id string, from time.Time, to time.Time, interval string) ([]HistoryPoint, error) {
+	unit, ok := HistoryIntervals[interval]
+	if !ok {
+		return nil, fmt.Errorf("unknown interval %s", interval)
+	}
+	// without an index the id is used as the product id
+	if index, err := GetIndex(ctx); err == nil {
+		if product, ok := index.Get(id); ok {
+			id = product.ProductId
+		}
+	}
+
+	rows, err := ctx.Pool.Query(*ctx.Context, getHistory, id, unit, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := make([]HistoryPoint, 0)
+	for rows.Next() {
+		var point HistoryPoint
+		var bucket time.Time
+		if err := rows.Scan(&bucket, &point.BuyPrice, &point.SellPrice, &point.BuyVolume, &point.SellVolume); err != nil {
+			return nil, err
+		}
+		point.Time = bucket.UnixMilli()
+		points = append(points, point)
+	}
+	return points, rows.Err()
+}
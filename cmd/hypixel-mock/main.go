@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"skyblock-pv-backend/internal/hypixelmock"
+	"strings"
+	"time"
+)
+
+func main() {
+	address := flag.String("addr", ":8081", "address to listen on")
+	fixtures := flag.String("fixtures", "", "directory to load fixtures from instead of the embedded ones")
+	keys := flag.String("keys", "", "comma separated list of accepted api keys, accepts any key if empty")
+	rateLimit := flag.Int("rate-limit", 0, "requests per key and window, 0 disables rate limiting")
+	rateLimitWindow := flag.Duration("rate-limit-window", 5*time.Minute, "rate limit window")
+	rules := flag.String("rules", "", "json file with rules to load on startup")
+	flag.Parse()
+
+	options := hypixelmock.Options{
+		RateLimit:       *rateLimit,
+		RateLimitWindow: *rateLimitWindow,
+	}
+	if *fixtures != "" {
+		options.Fixtures = os.DirFS(*fixtures)
+	}
+	if *keys != "" {
+		options.Keys = strings.Split(*keys, ",")
+	}
+	if *rules != "" {
+		data, err := os.ReadFile(*rules)
+		if err != nil {
+			panic(err)
+		}
+		if err := json.Unmarshal(data, &options.Rules); err != nil {
+			panic("Failed to parse rules: " + err.Error())
+		}
+	}
+
+	fmt.Printf("Mock hypixel api listening on %s\n", *address)
+	if err := http.ListenAndServe(*address, hypixelmock.NewServer(options)); err != nil {
+		panic(err)
+	}
+}
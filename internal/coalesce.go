@@ -0,0 +1,113 @@
+package internal
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const coalesceLockDuration = 15 * time.Second
+const coalescePollInterval = 100 * time.Millisecond
+
+var ErrNotFound = errors.New("not found")
+
+// ErrFailureCached is returned instead of the error of a fetch that failed recently
+var ErrFailureCached = errors.New("failure is cached")
+
+// stored in the error cache if hypixel had no data, other failures store an empty value
+const errorCauseNotFound = "not_found"
+
+// only deletes the lock if it is still held by us
+var releaseLock = redis.NewScript(`
+	if redis.call("get", KEYS[1]) == ARGV[1] then
+		return redis.call("del", KEYS[1])
+	end
+	return 0
+`)
+
+// FetchResult is shared between all requests waiting on the same cache miss
+type FetchResult struct {
+	Body   string
+	Status int
+	Ttl    time.Duration
+	// set when the body is a last known good copy served because hypixel failed
+	Stale bool
+	Age   time.Duration
+}
+
+// Coalesce runs fetch once for all concurrent misses of the same cache entry.
+// If enabled in the config, replicas coordinate using a redis lock and wait for the lock holder to fill the cache.
+func (ctx *RouteContext) Coalesce(path string, key string, fetch func() (*FetchResult, error)) (*FetchResult, error) {
+	result, err, _ := ctx.flights.Do(createKey(path, key), func() (interface{}, error) {
+		if ctx.redis != nil && ctx.Config.CoalesceAcrossReplicas {
+			return ctx.coalesceAcrossReplicas(path, key, fetch)
+		}
+		return fetch()
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result.(*FetchResult), nil
+}
+
+func (ctx *RouteContext) coalesceAcrossReplicas(path string, key string, fetch func() (*FetchResult, error)) (*FetchResult, error) {
+	background := context.Background()
+	lockKey := createKey(path, createKey(key, "lock"))
+	token, err := createLockToken()
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(coalesceLockDuration)
+	for time.Now().Before(deadline) {
+		acquired, err := ctx.redis.SetNX(background, lockKey, token, coalesceLockDuration).Result()
+		if err != nil {
+			return fetch()
+		}
+		if acquired {
+			defer releaseLock.Run(background, ctx.redis, []string{lockKey}, token)
+			return fetch()
+		}
+
+		for time.Now().Before(deadline) {
+			time.Sleep(coalescePollInterval)
+			if result, err, done := ctx.fromCache(path, key); done {
+				return result, err
+			}
+			if exists, err := ctx.redis.Exists(background, lockKey).Result(); err != nil || exists == 0 {
+				break
+			}
+		}
+	}
+
+	return fetch()
+}
+
+// fromCache builds the result another replica stored, done is false if it has not finished yet.
+// A cached ErrNotFound is returned as error so waiters answer like the lock holder.
+func (ctx *RouteContext) fromCache(path string, key string) (result *FetchResult, err error, done bool) {
+	if data, err := ctx.GetFromCacheByKey(createKey(path, key)); err == nil {
+		ttl, _ := ctx.GetTtlMilli(path, key)
+		return &FetchResult{Body: data, Status: http.StatusOK, Ttl: ttl * time.Millisecond}, nil, true
+	}
+	if cause, ok := ctx.GetErrorCached(path, key); ok {
+		if errors.Is(cause, ErrNotFound) {
+			return nil, cause, true
+		}
+		return &FetchResult{Status: http.StatusInternalServerError}, nil, true
+	}
+	return nil, nil, false
+}
+
+func createLockToken() (string, error) {
+	data := make([]byte, 16)
+	if _, err := rand.Read(data); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(data), nil
+}
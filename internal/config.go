@@ -2,26 +2,68 @@ package internal
 
 import (
 	"encoding/json"
+	"io"
 	"os"
+	"skyblock-pv-backend/utils"
+	"strings"
 )
 
+const defaultHypixelUrl = "https://api.hypixel.net"
+
 type Config struct {
-	RedisAddress        string          `json:"redis_address"`
-	RedisUsername       *string         `json:"redis_username,omitempty"`
-	RedisPassword       *string         `json:"redis_password,omitempty"`
-	JwtToken            string          `json:"jwt_token"`
-	HypixelKey          []string        `json:"hypixel_key"`
-	Port                string          `json:"port"`
-	Admins              []string        `json:"admins"`
-	DevMode             bool            `json:"dev_mode"`
-	HighProfileAccounts []string        `json:"high_profile_accounts"`
-	Endpoints           EndpointsConfig `json:"endpoints"`
-	PostgresUri         string          `json:"postgres_uri,omitempty"`
+	RedisAddress           string          `json:"redis_address"`
+	RedisUsername          *string         `json:"redis_username,omitempty"`
+	RedisPassword          *string         `json:"redis_password,omitempty"`
+	JwtToken               string          `json:"jwt_token"`
+	HypixelKey             []string        `json:"hypixel_key"`
+	Port                   string          `json:"port"`
+	Admins                 []string        `json:"admins"`
+	DevMode                bool            `json:"dev_mode"`
+	HighProfileAccounts    []string        `json:"high_profile_accounts"`
+	Endpoints              EndpointsConfig `json:"endpoints"`
+	PostgresUri            string          `json:"postgres_uri,omitempty"`
+	HypixelUrl             string          `json:"hypixel_url,omitempty"`
+	HypixelTimeoutMilli    int             `json:"hypixel_timeout_ms"` // per request, including reading the body
+	CoalesceAcrossReplicas bool            `json:"coalesce_across_replicas"`
+	Auctions               AuctionsConfig  `json:"auctions"`
+	Log                    LogConfig       `json:"log"`
+	Server                 ServerConfig    `json:"server"`
+	Health                 HealthConfig    `json:"health"`
+}
+
+type HealthConfig struct {
+	// the instance isn't ready if the loaded auctions are older than this
+	MaxAuctionAgeMilli int `json:"max_auction_age_ms"`
+}
+
+type ServerConfig struct {
+	// timeouts of the http server, see http.Server
+	ReadTimeoutMilli       int `json:"read_timeout_ms"`
+	ReadHeaderTimeoutMilli int `json:"read_header_timeout_ms"`
+	WriteTimeoutMilli      int `json:"write_timeout_ms"`
+	IdleTimeoutMilli       int `json:"idle_timeout_ms"`
+	// how long in-flight requests and background jobs get to finish after SIGTERM
+	ShutdownTimeoutMilli int `json:"shutdown_timeout_ms"`
+}
+
+type AuctionsConfig struct {
+	// amount of pages fetched in parallel
+	Workers int `json:"workers"`
+	// attempts per page after the first one failed (negative disables retries), waiting RetryDelayMilli doubled per attempt
+	Retries         int `json:"retries"`
+	RetryDelayMilli int `json:"retry_delay_ms"`
+	// share of pages that have to succeed to accept a snapshot, the previous snapshot is kept otherwise
+	MinPageRatio float64 `json:"min_page_ratio"`
+	// attributes that split the prices of an item into variants (see utils.FingerprintAttributes), all when missing
+	Fingerprint []string `json:"fingerprint"`
+	// enchantments that are part of the fingerprint besides the ultimate enchantments
+	FingerprintEnchantments []string `json:"fingerprint_enchantments"`
 }
 
 type EndpointsConfig struct {
 	Players      bool                       `json:"players"`
 	RateLimit    bool                       `json:"rate_limit"`
+	Metrics      bool                       `json:"metrics"`
 	Authenticate AuthenticateEndpointConfig `json:"authenticate"`
 }
 
@@ -42,5 +84,56 @@ func NewConfig() Config {
 	if err != nil {
 		panic("Failed to parse config: " + err.Error())
 	}
+	if config.HypixelUrl == "" {
+		config.HypixelUrl = defaultHypixelUrl
+	}
+	config.HypixelUrl = strings.TrimSuffix(config.HypixelUrl, "/")
+	if config.HypixelTimeoutMilli <= 0 {
+		config.HypixelTimeoutMilli = 30000
+	}
+	if config.Auctions.Workers <= 0 {
+		config.Auctions.Workers = 4
+	}
+	if config.Auctions.Retries < 0 {
+		config.Auctions.Retries = 0
+	} else if config.Auctions.Retries == 0 {
+		config.Auctions.Retries = 3
+	}
+	if config.Auctions.RetryDelayMilli <= 0 {
+		config.Auctions.RetryDelayMilli = 500
+	}
+	if config.Auctions.MinPageRatio <= 0 || config.Auctions.MinPageRatio > 1 {
+		config.Auctions.MinPageRatio = 0.95
+	}
+	if config.Auctions.Fingerprint == nil {
+		config.Auctions.Fingerprint = utils.FingerprintAttributes
+	}
+	if _, err := utils.NewFingerprinter(config.Auctions.Fingerprint, nil); err != nil {
+		panic("Failed to parse config: " + err.Error())
+	}
+	if config.Server.ReadTimeoutMilli <= 0 {
+		config.Server.ReadTimeoutMilli = 10000
+	}
+	if config.Server.ReadHeaderTimeoutMilli <= 0 {
+		config.Server.ReadHeaderTimeoutMilli = 5000
+	}
+	if config.Server.WriteTimeoutMilli <= 0 {
+		config.Server.WriteTimeoutMilli = 60000
+	}
+	if config.Server.IdleTimeoutMilli <= 0 {
+		config.Server.IdleTimeoutMilli = 120000
+	}
+	if config.Server.ShutdownTimeoutMilli <= 0 {
+		config.Server.ShutdownTimeoutMilli = 30000
+	}
+	if config.Health.MaxAuctionAgeMilli <= 0 {
+		config.Health.MaxAuctionAgeMilli = 600000
+	}
+	if config.Log.Level == "" {
+		config.Log.Level = "info"
+	}
+	if _, err := NewLogger(config.Log, io.Discard); err != nil {
+		panic("Failed to parse config: " + err.Error())
+	}
 	return config
 }
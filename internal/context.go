@@ -4,12 +4,17 @@ import (
 	"context"
 	"database/sql"
 	"embed"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
+	"os"
+	"skyblock-pv-backend/internal/metrics"
 	"slices"
 	"time"
 
 	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
 
 	"github.com/golang-migrate/migrate/v4"
 	migratepgx "github.com/golang-migrate/migrate/v4/database/pgx/v5"
@@ -17,15 +22,40 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+var (
+	cacheRequests = metrics.NewCounter(
+		"skyblock_cache_requests_total",
+		"Cache lookups by cache name and result (hit, miss or error), bypassed lookups are misses",
+		"cache", "result",
+	)
+	errorCacheHits = metrics.NewCounter(
+		"skyblock_error_cache_hits_total",
+		"Requests answered from the error cache without asking hypixel by cache name",
+		"cache",
+	)
+)
+
 type RouteContext struct {
 	redis   *redis.Client
 	Config  *Config
 	Pool    *pgxpool.Pool
 	Context *context.Context
+	Keys    *KeyPool
+	flights *singleflight.Group
+	Logger  *slog.Logger
+	// request is set on contexts created with WithRequest
+	request *requestLog
 }
 
-func NewRouteContext() RouteContext {
+// NewRouteContext connects to redis and postgres, root is cancelled once the server stopped to end background jobs
+func NewRouteContext(root context.Context) RouteContext {
 	config := NewConfig()
+	logger, err := NewLogger(config.Log, os.Stderr)
+	if err != nil {
+		panic(err)
+	}
+	slog.SetDefault(logger)
+
 	var client *redis.Client = nil
 	if config.RedisAddress != "" {
 		if config.RedisUsername == nil {
@@ -44,7 +74,7 @@ func NewRouteContext() RouteContext {
 		}
 	}
 
-	ctx := context.Background()
+	ctx := root
 	pool, err := pgxpool.New(ctx, config.PostgresUri)
 	if err != nil {
 		panic(err)
@@ -54,14 +84,77 @@ func NewRouteContext() RouteContext {
 		panic(err)
 	}
 
-	routeContext := RouteContext{client, &config, pool, &ctx}
+	routeContext := RouteContext{
+		redis:   client,
+		Config:  &config,
+		Pool:    pool,
+		Context: &ctx,
+		Keys:    NewKeyPool(config.HypixelKey),
+		flights: &singleflight.Group{},
+		Logger:  logger,
+	}
 	if err := setupDatabase(&routeContext); err != nil {
 		panic(err)
 	}
+	registerMetrics(&routeContext)
 
 	return routeContext
 }
 
+// registerMetrics adds the metrics that are read from the key and connection pools when they are scraped
+func registerMetrics(ctx *RouteContext) {
+	metrics.NewGaugeFunc(
+		"skyblock_hypixel_ratelimit_remaining",
+		"Remaining requests of every hypixel api key until the rate limit resets, missing if unknown",
+		[]string{"key"},
+		ctx.Keys.collectRemaining,
+	)
+
+	poolGauge := func(name string, help string, value func(stat *pgxpool.Stat) float64) {
+		metrics.NewGaugeFunc(name, help, nil, func(set func(value float64, values ...string)) {
+			set(value(ctx.Pool.Stat()))
+		})
+	}
+	poolCounter := func(name string, help string, value func(stat *pgxpool.Stat) float64) {
+		metrics.NewCounterFunc(name, help, nil, func(set func(value float64, values ...string)) {
+			set(value(ctx.Pool.Stat()))
+		})
+	}
+	poolGauge("skyblock_postgres_connections_total", "Open postgres connections", func(stat *pgxpool.Stat) float64 {
+		return float64(stat.TotalConns())
+	})
+	poolGauge("skyblock_postgres_connections_acquired", "Postgres connections in use", func(stat *pgxpool.Stat) float64 {
+		return float64(stat.AcquiredConns())
+	})
+	poolGauge("skyblock_postgres_connections_idle", "Idle postgres connections", func(stat *pgxpool.Stat) float64 {
+		return float64(stat.IdleConns())
+	})
+	poolGauge("skyblock_postgres_connections_max", "Maximum size of the postgres pool", func(stat *pgxpool.Stat) float64 {
+		return float64(stat.MaxConns())
+	})
+	poolCounter("skyblock_postgres_acquires_total", "Connections acquired from the postgres pool", func(stat *pgxpool.Stat) float64 {
+		return float64(stat.AcquireCount())
+	})
+	poolCounter("skyblock_postgres_acquire_waits_total", "Acquires that had to wait for a postgres connection", func(stat *pgxpool.Stat) float64 {
+		return float64(stat.EmptyAcquireCount())
+	})
+	poolCounter("skyblock_postgres_acquire_seconds_total", "Time spent acquiring postgres connections", func(stat *pgxpool.Stat) float64 {
+		return stat.AcquireDuration().Seconds()
+	})
+}
+
+// Close closes the redis client and then the postgres pool, it waits for acquired connections to be released
+func (ctx *RouteContext) Close() {
+	if ctx.redis != nil {
+		if err := ctx.redis.Close(); err != nil {
+			ctx.Logger.Error("Failed to close redis", "err", err)
+		}
+	}
+	if ctx.Pool != nil {
+		ctx.Pool.Close()
+	}
+}
+
 func (ctx *RouteContext) IsHighProfileAccount(playerId string) bool {
 	if ctx.Config == nil {
 		return false
@@ -104,11 +197,25 @@ func (ctx *RouteContext) IsCached(path string, key string) bool {
 }
 
 func (ctx *RouteContext) HasErrorCached(path string, key string) bool {
+	_, ok := ctx.GetErrorCached(path, key)
+	return ok
+}
+
+// GetErrorCached returns the error stored by AddToErrorCache, ErrNotFound if hypixel had no data and
+// ErrFailureCached for every other failure
+func (ctx *RouteContext) GetErrorCached(path string, key string) (error, bool) {
 	if ctx.redis == nil {
-		return false
+		return nil, false
 	}
 	result := ctx.redis.Get(context.Background(), createKey(path, createKey(key, "error")))
-	return result.Err() == nil
+	if result.Err() != nil {
+		return nil, false
+	}
+	errorCacheHits.Inc(path)
+	if result.Val() == errorCauseNotFound {
+		return ErrNotFound, true
+	}
+	return ErrFailureCached, true
 }
 
 func (ctx *RouteContext) GetTtlMilli(path string, key string) (time.Duration, error) {
@@ -143,9 +250,19 @@ func (ctx *RouteContext) GetFromCacheByKey(key string) (string, error) {
 
 func (ctx *RouteContext) GetFromCache(authContext *AuthenticationContext, path string, key string) (string, error) {
 	if authContext != nil && (*authContext).BypassCache {
+		cacheRequests.Inc(path, "miss")
 		return "", fmt.Errorf("not found")
 	}
-	return ctx.GetFromCacheByKey(createKey(path, key))
+	value, err := ctx.GetFromCacheByKey(createKey(path, key))
+	switch {
+	case err == nil:
+		cacheRequests.Inc(path, "hit")
+	case ctx.redis == nil || errors.Is(err, redis.Nil):
+		cacheRequests.Inc(path, "miss")
+	default:
+		cacheRequests.Inc(path, "error")
+	}
+	return value, err
 }
 
 func (ctx *RouteContext) AddToCache(path string, key string, value interface{}, duration time.Duration) error {
@@ -156,14 +273,49 @@ func (ctx *RouteContext) AddToCache(path string, key string, value interface{},
 	return result.Err()
 }
 
-func (ctx *RouteContext) AddToErrorCache(path string, key string, duration time.Duration) error {
+// AddToErrorCache remembers that fetching the key failed, whether the cause was ErrNotFound is stored as well
+func (ctx *RouteContext) AddToErrorCache(path string, key string, cause error, duration time.Duration) error {
+	if ctx.redis == nil {
+		return nil
+	}
+	value := ""
+	if errors.Is(cause, ErrNotFound) {
+		value = errorCauseNotFound
+	}
+	result := ctx.redis.Set(context.Background(), createKey(path, createKey(key, "error")), value, duration)
+	return result.Err()
+}
+
+type staleEntry struct {
+	Body     string `json:"body"`
+	StoredAt int64  `json:"stored_at"`
+}
+
+// AddToStaleCache keeps a last known good copy next to the regular entry, it is served when hypixel can't be reached
+func (ctx *RouteContext) AddToStaleCache(path string, key string, value string, duration time.Duration) error {
 	if ctx.redis == nil {
 		return nil
 	}
-	result := ctx.redis.Set(context.Background(), createKey(path, createKey(key, "error")), "", duration)
+	data, err := json.Marshal(staleEntry{value, time.Now().UnixMilli()})
+	if err != nil {
+		return err
+	}
+	result := ctx.redis.Set(context.Background(), createKey(path, createKey(key, "stale")), data, duration)
 	return result.Err()
 }
 
+func (ctx *RouteContext) GetFromStaleCache(path string, key string) (string, time.Time, error) {
+	data, err := ctx.GetFromCacheByKey(createKey(path, createKey(key, "stale")))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	var entry staleEntry
+	if err := json.Unmarshal([]byte(data), &entry); err != nil {
+		return "", time.Time{}, err
+	}
+	return entry.Body, time.UnixMilli(entry.StoredAt), nil
+}
+
 //go:embed migrations/*.sql
 var migrationFS embed.FS
 
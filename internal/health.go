@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+var ErrRedisDisabled = errors.New("redis is not configured")
+
+// set when building with -ldflags "-X skyblock-pv-backend/internal.Version=... -X skyblock-pv-backend/internal.Commit=..."
+var (
+	Version = "dev"
+	Commit  = ""
+)
+
+// BuildCommit returns Commit, or the revision go stamped into the binary if it wasn't set
+func BuildCommit() string {
+	if Commit != "" {
+		return Commit
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" {
+				return setting.Value
+			}
+		}
+	}
+	return "unknown"
+}
+
+func (ctx *RouteContext) PingRedis(c context.Context) error {
+	if ctx.redis == nil {
+		return ErrRedisDisabled
+	}
+	return ctx.redis.Ping(c).Err()
+}
+
+// MigrationVersion returns the version the database was migrated to, dirty is set if a migration failed halfway
+func (ctx *RouteContext) MigrationVersion(c context.Context) (version uint, dirty bool, err error) {
+	err = ctx.Pool.QueryRow(c, "select version, dirty from schema_migrations limit 1").Scan(&version, &dirty)
+	return version, dirty, err
+}
+
+// LatestMigration returns the version of the newest migration embedded in the binary
+func LatestMigration() (uint, error) {
+	entries, err := migrationFS.ReadDir("migrations")
+	if err != nil {
+		return 0, err
+	}
+	latest := uint(0)
+	for _, entry := range entries {
+		prefix, _, _ := strings.Cut(entry.Name(), "_")
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		latest = max(latest, uint(version))
+	}
+	return latest, nil
+}
@@ -1,32 +1,70 @@
 package internal
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"skyblock-pv-backend/internal/metrics"
 	"strconv"
+	"strings"
+	"time"
 )
 
-var RateLimitRemaining = 0
-var RateLimitReset = 0
+var errRetryWithOtherKey = errors.New("retry with another key")
 
-func updateRateLimit(res *http.Response) {
-	remaining := res.Header.Get("RateLimit-Remaining")
-	reset := res.Header.Get("RateLimit-Reset")
+var (
+	upstreamRequests = metrics.NewCounter(
+		"skyblock_hypixel_requests_total",
+		"Requests to the hypixel api by path, api key and status, failed connections have the status error",
+		"path", "key", "status",
+	)
+	upstreamDuration = metrics.NewHistogram(
+		"skyblock_hypixel_request_duration_seconds",
+		"Duration of requests to the hypixel api by path and api key",
+		metrics.DefaultBuckets,
+		"path", "key",
+	)
+)
 
-	if val, err := strconv.Atoi(remaining); err == nil {
-		RateLimitRemaining = val
+func GetFromHypixel(ctx RouteContext, path string, requiresAuth bool) (*string, error) {
+	if !requiresAuth {
+		return requestHypixel(ctx, path, nil)
 	}
 
-	if val, err := strconv.Atoi(reset); err == nil {
-		RateLimitReset = val
+	// every key is tried at most once, throttled and invalid keys are skipped by the pool
+	for range ctx.Keys.Size() {
+		key, err := ctx.Keys.acquire()
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := requestHypixel(ctx, path, key)
+		if errors.Is(err, errRetryWithOtherKey) {
+			continue
+		}
+		return data, err
 	}
+	return nil, ErrNoKeyAvailable
 }
 
-func GetFromHypixel(ctx RouteContext, path string, requiresAuth bool) (*string, error) {
-	req, err := http.NewRequest(
+// shared by all requests so connections to hypixel are reused
+var hypixelClient = &http.Client{}
+
+func requestHypixel(ctx RouteContext, path string, key *apiKey) (*string, error) {
+	// background jobs use the root context, without a timeout a stalled response would block them forever
+	requestContext := *ctx.Context
+	if ctx.Config.HypixelTimeoutMilli > 0 {
+		var cancel context.CancelFunc
+		requestContext, cancel = context.WithTimeout(requestContext, time.Duration(ctx.Config.HypixelTimeoutMilli)*time.Millisecond)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(
+		requestContext,
 		"GET",
-		fmt.Sprintf("https://api.hypixel.net%s", path),
+		ctx.Config.HypixelUrl+path,
 		nil,
 	)
 
@@ -34,30 +72,41 @@ func GetFromHypixel(ctx RouteContext, path string, requiresAuth bool) (*string,
 		return nil, err
 	}
 
-	if requiresAuth {
-		lastChar := path[len(path)-1]
-		key := ctx.Config.HypixelKey[int(lastChar)%len(ctx.Config.HypixelKey)]
-		req.Header.Set("API-Key", key)
+	if key != nil {
+		req.Header.Set("API-Key", key.key)
 	}
 
-	client := http.Client{}
-
-	res, err := client.Do(req)
+	// ids are passed in the query, the path alone keeps the amount of series small
+	metricPath, _, _ := strings.Cut(path, "?")
+	metricKey := "none"
+	if key != nil {
+		metricKey = key.id
+	}
+	start := time.Now()
+	res, err := hypixelClient.Do(req)
+	upstreamDuration.Observe(time.Since(start).Seconds(), metricPath, metricKey)
 
 	if err != nil {
+		upstreamRequests.Inc(metricPath, metricKey, "error")
 		return nil, err
 	}
+	upstreamRequests.Inc(metricPath, metricKey, strconv.Itoa(res.StatusCode))
 
 	defer res.Body.Close()
 
+	if key != nil {
+		ctx.Keys.update(key, res)
+		if res.StatusCode == http.StatusForbidden || res.StatusCode == http.StatusTooManyRequests {
+			return nil, errRetryWithOtherKey
+		}
+	}
+
 	if res.StatusCode == http.StatusNotFound {
 		return nil, nil
 	} else if res.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("failed to fetch data: %s", res.Status)
 	}
 
-	updateRateLimit(res)
-
 	data, err := io.ReadAll(res.Body)
 
 	if err != nil {
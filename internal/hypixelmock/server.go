@@ -0,0 +1,280 @@
+package hypixelmock
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// a fake hypixel api serving recorded responses, used to run the backend without network access
+
+//go:embed fixtures
+var embeddedFixtures embed.FS
+
+const rulesPath = "/_mock/rules"
+
+type endpoint struct {
+	directory   string
+	parameter   string
+	requiresKey bool
+}
+
+var endpoints = map[string]endpoint{
+	"/v2/skyblock/profiles":       {"profiles", "uuid", true},
+	"/v2/skyblock/garden":         {"garden", "profile", true},
+	"/v2/skyblock/museum":         {"museum", "profile", true},
+	"/v2/guild":                   {"guild", "player", true},
+	"/v2/status":                  {"status", "uuid", true},
+	"/v2/player":                  {"player", "uuid", true},
+	"/v2/skyblock/auction":        {"auction", "profile", true},
+	"/v2/skyblock/auctions":       {"auctions", "page", false},
+	"/v2/skyblock/auctions_ended": {"auctions_ended", "", false},
+	"/v2/skyblock/bazaar":         {"bazaar", "", false},
+}
+
+// Rule overrides the response for matching requests, Key matches the request parameter (e.g. the uuid) when set.
+// Times limits how often the rule applies, zero means forever.
+type Rule struct {
+	Path       string `json:"path"`
+	Key        string `json:"key,omitempty"`
+	Status     int    `json:"status,omitempty"`
+	DelayMilli int    `json:"delay_ms,omitempty"`
+	RetryAfter int    `json:"retry_after,omitempty"`
+	Times      int    `json:"times,omitempty"`
+}
+
+type Options struct {
+	// Fixtures to serve, defaults to the recorded fixtures embedded in this package
+	Fixtures fs.FS
+	// Keys accepted as API-Key, any non-empty key is accepted when empty
+	Keys []string
+	// RateLimit is the amount of requests a key can do per RateLimitWindow, zero disables rate limiting
+	RateLimit       int
+	RateLimitWindow time.Duration
+	Rules           []Rule
+}
+
+type usage struct {
+	count int
+	reset time.Time
+}
+
+type Server struct {
+	fixtures fs.FS
+	options  Options
+	mutex    sync.Mutex
+	rules    []*Rule
+	usage    map[string]*usage
+}
+
+func NewServer(options Options) *Server {
+	fixtures := options.Fixtures
+	if fixtures == nil {
+		fixtures, _ = fs.Sub(embeddedFixtures, "fixtures")
+	}
+	if options.RateLimitWindow <= 0 {
+		options.RateLimitWindow = 5 * time.Minute
+	}
+	server := &Server{fixtures: fixtures, options: options, usage: map[string]*usage{}}
+	server.SetRules(options.Rules)
+	return server
+}
+
+func (server *Server) SetRules(rules []Rule) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	server.rules = make([]*Rule, len(rules))
+	for i := range rules {
+		rule := rules[i]
+		server.rules[i] = &rule
+	}
+}
+
+func (server *Server) AddRule(rule Rule) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	server.rules = append(server.rules, &rule)
+}
+
+func (server *Server) Rules() []Rule {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	rules := make([]Rule, len(server.rules))
+	for i, rule := range server.rules {
+		rules[i] = *rule
+	}
+	return rules
+}
+
+func (server *Server) ServeHTTP(res http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == rulesPath {
+		server.handleRules(res, req)
+		return
+	}
+
+	endpoint, ok := endpoints[req.URL.Path]
+	if !ok || req.Method != http.MethodGet {
+		writeFailure(res, http.StatusNotFound, "Not found")
+		return
+	}
+
+	key := req.Header.Get("API-Key")
+	if endpoint.requiresKey {
+		if key == "" || (len(server.options.Keys) > 0 && !slices.Contains(server.options.Keys, key)) {
+			writeFailure(res, http.StatusForbidden, "Invalid API key")
+			return
+		}
+		if !server.consume(res, key) {
+			return
+		}
+	}
+
+	value := req.URL.Query().Get(endpoint.parameter)
+	if endpoint.parameter == "page" && value == "" {
+		value = "0"
+	}
+	if endpoint.parameter != "" && value == "" {
+		writeFailure(res, http.StatusBadRequest, fmt.Sprintf("Missing one or more fields [%s]", endpoint.parameter))
+		return
+	}
+	value = normalize(value)
+
+	if rule := server.match(req.URL.Path, value); rule != nil {
+		if rule.DelayMilli > 0 {
+			select {
+			case <-time.After(time.Duration(rule.DelayMilli) * time.Millisecond):
+			case <-req.Context().Done():
+				return
+			}
+		}
+		if rule.Status != 0 {
+			if rule.RetryAfter > 0 {
+				res.Header().Set("Retry-After", strconv.Itoa(rule.RetryAfter))
+			}
+			writeFailure(res, rule.Status, http.StatusText(rule.Status))
+			return
+		}
+	}
+
+	fixture := fmt.Sprintf("%s/%s.json", endpoint.directory, value)
+	if endpoint.parameter == "" {
+		fixture = endpoint.directory + ".json"
+	}
+	data, err := fs.ReadFile(server.fixtures, fixture)
+	if errors.Is(err, fs.ErrNotExist) {
+		writeFailure(res, http.StatusNotFound, "No fixture found")
+		return
+	} else if err != nil {
+		writeFailure(res, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	_, _ = res.Write(data)
+}
+
+// consume counts a request against the key and writes the rate limit headers, returns false if the key is throttled
+func (server *Server) consume(res http.ResponseWriter, key string) bool {
+	if server.options.RateLimit <= 0 {
+		return true
+	}
+
+	server.mutex.Lock()
+	now := time.Now()
+	current := server.usage[key]
+	if current == nil || now.After(current.reset) {
+		current = &usage{0, now.Add(server.options.RateLimitWindow)}
+		server.usage[key] = current
+	}
+	current.count++
+	remaining := max(server.options.RateLimit-current.count, 0)
+	reset := int(current.reset.Sub(now).Seconds())
+	throttled := current.count > server.options.RateLimit
+	server.mutex.Unlock()
+
+	res.Header().Set("RateLimit-Limit", strconv.Itoa(server.options.RateLimit))
+	res.Header().Set("RateLimit-Remaining", strconv.Itoa(remaining))
+	res.Header().Set("RateLimit-Reset", strconv.Itoa(reset))
+
+	if throttled {
+		res.Header().Set("Retry-After", strconv.Itoa(reset))
+		writeFailure(res, http.StatusTooManyRequests, "Key throttle")
+		return false
+	}
+	return true
+}
+
+func (server *Server) match(path string, value string) *Rule {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	for i, rule := range server.rules {
+		if rule.Path != path || (rule.Key != "" && normalize(rule.Key) != value) {
+			continue
+		}
+		matched := *rule
+		if rule.Times > 0 {
+			rule.Times--
+			if rule.Times == 0 {
+				server.rules = slices.Delete(server.rules, i, i+1)
+			}
+		}
+		return &matched
+	}
+	return nil
+}
+
+func (server *Server) handleRules(res http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case http.MethodGet:
+		res.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(res).Encode(server.Rules())
+	case http.MethodPost, http.MethodPut:
+		data, err := io.ReadAll(req.Body)
+		if err != nil {
+			res.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var rules []Rule
+		if err := json.Unmarshal(data, &rules); err != nil {
+			var rule Rule
+			if err := json.Unmarshal(data, &rule); err != nil {
+				res.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			rules = []Rule{rule}
+		}
+		if req.Method == http.MethodPut {
+			server.SetRules(rules)
+		} else {
+			for _, rule := range rules {
+				server.AddRule(rule)
+			}
+		}
+		res.WriteHeader(http.StatusNoContent)
+	case http.MethodDelete:
+		server.SetRules(nil)
+		res.WriteHeader(http.StatusNoContent)
+	default:
+		res.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func normalize(value string) string {
+	return strings.ToLower(strings.ReplaceAll(value, "-", ""))
+}
+
+func writeFailure(res http.ResponseWriter, status int, cause string) {
+	data, _ := json.Marshal(map[string]interface{}{"success": false, "cause": cause})
+	res.Header().Set("Content-Type", "application/json")
+	res.WriteHeader(status)
+	_, _ = res.Write(data)
+}
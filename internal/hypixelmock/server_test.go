@@ -0,0 +1,226 @@
+package hypixelmock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testKey     = "test-key"
+	testPlayer  = "8d3f1b2e-4c5a-4e6f-9a7b-1c2d3e4f5a6b"
+	testProfile = "a1b2c3d4e5f64789abcdef0123456789"
+)
+
+func startServer(t *testing.T, options Options) (*Server, *httptest.Server) {
+	t.Helper()
+	server := NewServer(options)
+	listener := httptest.NewServer(server)
+	t.Cleanup(listener.Close)
+	return server, listener
+}
+
+// get requests the path with the key as API-Key unless it is empty
+func get(t *testing.T, listener *httptest.Server, path string, key string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, listener.URL+path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != "" {
+		req.Header.Set("API-Key", key)
+	}
+	res, err := listener.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = res.Body.Close() })
+	return res
+}
+
+func expectStatus(t *testing.T, res *http.Response, status int) {
+	t.Helper()
+	if res.StatusCode != status {
+		t.Errorf("%s: got status %d, expected %d", res.Request.URL.RequestURI(), res.StatusCode, status)
+	}
+}
+
+func TestFixtures(t *testing.T) {
+	_, listener := startServer(t, Options{})
+
+	res := get(t, listener, "/v2/skyblock/profiles?uuid="+testPlayer, testKey)
+	expectStatus(t, res, http.StatusOK)
+	var body struct {
+		Success bool `json:"success"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil || !body.Success {
+		t.Errorf("profiles fixture was not served: %v", err)
+	}
+
+	// ids are matched without dashes and case
+	expectStatus(t, get(t, listener, "/v2/skyblock/profiles?uuid="+strings.ToUpper(testPlayer), testKey), http.StatusOK)
+	expectStatus(t, get(t, listener, "/v2/skyblock/garden?profile="+testProfile, testKey), http.StatusOK)
+	expectStatus(t, get(t, listener, "/v2/skyblock/auctions", ""), http.StatusOK)
+	expectStatus(t, get(t, listener, "/v2/skyblock/auctions?page=1", ""), http.StatusOK)
+	expectStatus(t, get(t, listener, "/v2/skyblock/bazaar", ""), http.StatusOK)
+
+	expectStatus(t, get(t, listener, "/v2/skyblock/auctions?page=2", ""), http.StatusNotFound)
+	expectStatus(t, get(t, listener, "/v2/skyblock/profiles?uuid=unknown", testKey), http.StatusNotFound)
+	expectStatus(t, get(t, listener, "/v2/skyblock/profiles", testKey), http.StatusBadRequest)
+	expectStatus(t, get(t, listener, "/v2/unknown", testKey), http.StatusNotFound)
+}
+
+func TestApiKey(t *testing.T) {
+	_, listener := startServer(t, Options{Keys: []string{testKey}})
+	profiles := "/v2/skyblock/profiles?uuid=" + testPlayer
+
+	expectStatus(t, get(t, listener, profiles, ""), http.StatusForbidden)
+	expectStatus(t, get(t, listener, profiles, "other-key"), http.StatusForbidden)
+	expectStatus(t, get(t, listener, profiles, testKey), http.StatusOK)
+	// public endpoints don't need a key
+	expectStatus(t, get(t, listener, "/v2/skyblock/bazaar", ""), http.StatusOK)
+
+	// without configured keys every key is accepted, but one is still required
+	_, open := startServer(t, Options{})
+	expectStatus(t, get(t, open, profiles, "any-key"), http.StatusOK)
+	expectStatus(t, get(t, open, profiles, ""), http.StatusForbidden)
+}
+
+func TestRuleMatching(t *testing.T) {
+	server, listener := startServer(t, Options{})
+	profiles := "/v2/skyblock/profiles?uuid=" + testPlayer
+	server.AddRule(Rule{Path: "/v2/skyblock/profiles", Key: strings.ToUpper(testPlayer), Status: http.StatusBadGateway, Times: 2})
+	server.AddRule(Rule{Path: "/v2/skyblock/profiles", Status: http.StatusServiceUnavailable})
+	server.AddRule(Rule{Path: "/v2/skyblock/museum", Status: http.StatusInternalServerError})
+
+	// the first matching rule applies, keys are normalized like the request parameter
+	expectStatus(t, get(t, listener, profiles, testKey), http.StatusBadGateway)
+	if rules := server.Rules(); len(rules) != 3 || rules[0].Times != 1 {
+		t.Errorf("rule was not counted down: %+v", rules)
+	}
+	expectStatus(t, get(t, listener, profiles, testKey), http.StatusBadGateway)
+	// the rule is removed after it applied Times times, the next one matches every key
+	if rules := server.Rules(); len(rules) != 2 {
+		t.Errorf("used up rule was not removed: %+v", rules)
+	}
+	expectStatus(t, get(t, listener, profiles, testKey), http.StatusServiceUnavailable)
+	expectStatus(t, get(t, listener, "/v2/skyblock/profiles?uuid=other", testKey), http.StatusServiceUnavailable)
+	// rules only match their path
+	expectStatus(t, get(t, listener, "/v2/skyblock/garden?profile="+testProfile, testKey), http.StatusOK)
+
+	server.SetRules(nil)
+	expectStatus(t, get(t, listener, profiles, testKey), http.StatusOK)
+}
+
+func TestRulesEndpoint(t *testing.T) {
+	server, listener := startServer(t, Options{Rules: []Rule{{Path: "/v2/skyblock/bazaar", Status: http.StatusBadGateway}}})
+	send := func(method string, body string) {
+		req, _ := http.NewRequest(method, listener.URL+rulesPath, strings.NewReader(body))
+		res, err := listener.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = res.Body.Close()
+		if res.StatusCode != http.StatusNoContent {
+			t.Errorf("%s %s: got status %d", method, body, res.StatusCode)
+		}
+	}
+
+	send(http.MethodPost, `{"path":"/v2/skyblock/auctions","status":500}`)
+	if rules := server.Rules(); len(rules) != 2 || rules[1].Path != "/v2/skyblock/auctions" {
+		t.Errorf("single rule was not added: %+v", rules)
+	}
+	send(http.MethodPut, `[{"path":"/v2/status","status":429,"retry_after":3}]`)
+	if rules := server.Rules(); len(rules) != 1 || rules[0].RetryAfter != 3 {
+		t.Errorf("rules were not replaced: %+v", rules)
+	}
+
+	res := get(t, listener, rulesPath, "")
+	var rules []Rule
+	if err := json.NewDecoder(res.Body).Decode(&rules); err != nil || len(rules) != 1 || rules[0].Path != "/v2/status" {
+		t.Errorf("rules endpoint returned %+v, %v", rules, err)
+	}
+
+	send(http.MethodDelete, "")
+	if rules := server.Rules(); len(rules) != 0 {
+		t.Errorf("rules were not deleted: %+v", rules)
+	}
+}
+
+func TestForcedRateLimit(t *testing.T) {
+	_, listener := startServer(t, Options{Rules: []Rule{
+		{Path: "/v2/skyblock/profiles", Status: http.StatusTooManyRequests, RetryAfter: 7, Times: 1},
+	}})
+	profiles := "/v2/skyblock/profiles?uuid=" + testPlayer
+
+	res := get(t, listener, profiles, testKey)
+	expectStatus(t, res, http.StatusTooManyRequests)
+	if retryAfter := res.Header.Get("Retry-After"); retryAfter != "7" {
+		t.Errorf("got Retry-After %q, expected 7", retryAfter)
+	}
+	var body struct {
+		Success bool   `json:"success"`
+		Cause   string `json:"cause"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil || body.Success || body.Cause == "" {
+		t.Errorf("failure body was %+v, %v", body, err)
+	}
+	expectStatus(t, get(t, listener, profiles, testKey), http.StatusOK)
+}
+
+func TestRateLimit(t *testing.T) {
+	_, listener := startServer(t, Options{RateLimit: 2, RateLimitWindow: time.Minute})
+	profiles := "/v2/skyblock/profiles?uuid=" + testPlayer
+
+	res := get(t, listener, profiles, testKey)
+	expectStatus(t, res, http.StatusOK)
+	if res.Header.Get("RateLimit-Limit") != "2" || res.Header.Get("RateLimit-Remaining") != "1" {
+		t.Errorf("got rate limit headers %v", res.Header)
+	}
+	expectStatus(t, get(t, listener, profiles, testKey), http.StatusOK)
+
+	res = get(t, listener, profiles, testKey)
+	expectStatus(t, res, http.StatusTooManyRequests)
+	if res.Header.Get("Retry-After") == "" || res.Header.Get("RateLimit-Remaining") != "0" {
+		t.Errorf("throttled response has headers %v", res.Header)
+	}
+	// keys are limited separately
+	expectStatus(t, get(t, listener, profiles, "other-key"), http.StatusOK)
+}
+
+func TestDelay(t *testing.T) {
+	const delay = 200 * time.Millisecond
+	_, listener := startServer(t, Options{Rules: []Rule{
+		{Path: "/v2/skyblock/bazaar", DelayMilli: int(delay.Milliseconds())},
+		{Path: "/v2/skyblock/auctions", DelayMilli: int(delay.Milliseconds()), Status: http.StatusBadGateway},
+	}})
+
+	start := time.Now()
+	expectStatus(t, get(t, listener, "/v2/skyblock/bazaar", ""), http.StatusOK)
+	if elapsed := time.Since(start); elapsed < delay {
+		t.Errorf("response took %s, expected at least %s", elapsed, delay)
+	}
+	// the status of a rule is sent after the delay
+	start = time.Now()
+	expectStatus(t, get(t, listener, "/v2/skyblock/auctions", ""), http.StatusBadGateway)
+	if elapsed := time.Since(start); elapsed < delay {
+		t.Errorf("failure took %s, expected at least %s", elapsed, delay)
+	}
+
+	// clients that give up don't wait for the delay
+	timeout, cancel := context.WithTimeout(context.Background(), delay/4)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(timeout, http.MethodGet, listener.URL+"/v2/skyblock/bazaar", nil)
+	res, err := listener.Client().Do(req)
+	if err == nil {
+		_ = res.Body.Close()
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the request to time out, got %v", err)
+	}
+}
@@ -0,0 +1,205 @@
+package internal
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const invalidKeyCooldown = time.Hour
+const defaultRetryAfter = 10 * time.Second
+
+var ErrNoKeyAvailable = errors.New("no hypixel api key available")
+
+type apiKey struct {
+	key           string
+	id            string // position in the config, used instead of the key in metrics
+	remaining     int
+	reset         time.Time
+	unavailable   time.Time
+	invalid       bool
+	requests      int64
+	failures      int64
+	lastFailure   string
+	lastFailureAt time.Time
+}
+
+// KeyPool hands out the api key with the most remaining requests and keeps throttled or invalid keys out of rotation
+type KeyPool struct {
+	mutex sync.Mutex
+	keys  []*apiKey
+}
+
+type KeyState struct {
+	Key            string `json:"key"`
+	Remaining      int    `json:"remaining"`
+	Reset          int    `json:"reset"`
+	Available      bool   `json:"available"`
+	UnavailableFor int    `json:"unavailable_for,omitempty"`
+	Invalid        bool   `json:"invalid"`
+	Requests       int64  `json:"requests"`
+	Failures       int64  `json:"failures"`
+	LastFailure    string `json:"last_failure,omitempty"`
+	LastFailureAt  int64  `json:"last_failure_at,omitempty"`
+}
+
+func NewKeyPool(keys []string) *KeyPool {
+	pool := &KeyPool{keys: make([]*apiKey, len(keys))}
+	for i, key := range keys {
+		pool.keys[i] = &apiKey{key: key, id: strconv.Itoa(i), remaining: -1}
+	}
+	return pool
+}
+
+func (pool *KeyPool) Size() int {
+	return len(pool.keys)
+}
+
+func (key *apiKey) headroom(now time.Time) int {
+	if now.Before(key.unavailable) {
+		return -1
+	}
+	if key.remaining < 0 || now.After(key.reset) {
+		return math.MaxInt
+	}
+	return key.remaining
+}
+
+// acquire reserves a request on the key with the most headroom
+func (pool *KeyPool) acquire() (*apiKey, error) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	now := time.Now()
+	var best *apiKey
+	bestHeadroom := 0
+	for _, key := range pool.keys {
+		headroom := key.headroom(now)
+		if headroom > bestHeadroom || (best != nil && headroom == bestHeadroom && key.requests < best.requests) {
+			best = key
+			bestHeadroom = headroom
+		}
+	}
+	if best == nil {
+		return nil, ErrNoKeyAvailable
+	}
+
+	best.requests++
+	if best.remaining > 0 && now.Before(best.reset) {
+		best.remaining--
+	}
+	return best, nil
+}
+
+// NextAvailable returns when the next key will be usable again, or now if one is usable already
+func (pool *KeyPool) NextAvailable() time.Time {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	now := time.Now()
+	next := time.Time{}
+	for _, key := range pool.keys {
+		if key.headroom(now) > 0 {
+			return now
+		}
+		available := key.unavailable
+		if now.After(available) {
+			available = key.reset
+		}
+		if next.IsZero() || available.Before(next) {
+			next = available
+		}
+	}
+	return next
+}
+
+func (pool *KeyPool) update(key *apiKey, res *http.Response) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	now := time.Now()
+	if reset, err := strconv.Atoi(res.Header.Get("RateLimit-Reset")); err == nil {
+		key.reset = now.Add(time.Duration(reset) * time.Second)
+	}
+	if remaining, err := strconv.Atoi(res.Header.Get("RateLimit-Remaining")); err == nil {
+		key.remaining = remaining
+	}
+
+	switch res.StatusCode {
+	case http.StatusForbidden:
+		key.invalid = true
+		key.unavailable = now.Add(invalidKeyCooldown)
+		key.fail(res.Status, now)
+	case http.StatusTooManyRequests:
+		retryAfter := defaultRetryAfter
+		if seconds, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil {
+			retryAfter = time.Duration(seconds) * time.Second
+		} else if now.Before(key.reset) {
+			retryAfter = key.reset.Sub(now)
+		}
+		key.remaining = 0
+		key.unavailable = now.Add(retryAfter)
+		key.fail(res.Status, now)
+	default:
+		key.invalid = false
+	}
+}
+
+func (key *apiKey) fail(reason string, now time.Time) {
+	key.failures++
+	key.lastFailure = reason
+	key.lastFailureAt = now
+}
+
+func (pool *KeyPool) States() []KeyState {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	now := time.Now()
+	states := make([]KeyState, len(pool.keys))
+	for i, key := range pool.keys {
+		state := KeyState{
+			Key:       maskKey(key.key),
+			Remaining: key.remaining,
+			Available: key.headroom(now) > 0,
+			Invalid:   key.invalid,
+			Requests:  key.requests,
+			Failures:  key.failures,
+		}
+		if now.Before(key.reset) {
+			state.Reset = int(key.reset.Sub(now).Seconds())
+		}
+		if now.Before(key.unavailable) {
+			state.UnavailableFor = int(key.unavailable.Sub(now).Seconds())
+		}
+		if key.lastFailure != "" {
+			state.LastFailure = key.lastFailure
+			state.LastFailureAt = key.lastFailureAt.UnixMilli()
+		}
+		states[i] = state
+	}
+	return states
+}
+
+// collectRemaining reports the remaining requests of every key with a known rate limit
+func (pool *KeyPool) collectRemaining(set func(value float64, values ...string)) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	now := time.Now()
+	for _, key := range pool.keys {
+		if key.remaining >= 0 && now.Before(key.reset) {
+			set(float64(key.remaining), key.id)
+		}
+	}
+}
+
+func maskKey(key string) string {
+	if len(key) <= 8 {
+		return "****"
+	}
+	return key[:4] + "****" + key[len(key)-4:]
+}
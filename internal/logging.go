@@ -0,0 +1,125 @@
+package internal
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cache status of a request, requests that don't use the cache have none
+const (
+	CacheHit   = "hit"
+	CacheMiss  = "miss"
+	CacheStale = "stale"
+)
+
+type LogConfig struct {
+	// Format is text (default) or json
+	Format string `json:"format"`
+	// Level is debug, info (default), warn or error
+	Level string `json:"level"`
+}
+
+func NewLogger(config LogConfig, writer io.Writer) (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(config.Level)); err != nil {
+		return nil, fmt.Errorf("unknown log level %s", config.Level)
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+	switch strings.ToLower(config.Format) {
+	case "", "text":
+		return slog.New(slog.NewTextHandler(writer, options)), nil
+	case "json":
+		return slog.New(slog.NewJSONHandler(writer, options)), nil
+	}
+	return nil, fmt.Errorf("unknown log format %s", config.Format)
+}
+
+// ids sent by a proxy in front of the backend are kept if they look like ids
+var validRequestId = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestId returns the X-Request-Id of the request or a new random id
+func RequestId(req *http.Request) string {
+	if id := req.Header.Get("X-Request-Id"); validRequestId.MatchString(id) {
+		return id
+	}
+	data := make([]byte, 16)
+	_, _ = rand.Read(data)
+	return hex.EncodeToString(data)
+}
+
+// requestLog collects the fields of the access log that are only known while the request is handled
+type requestLog struct {
+	mutex     sync.Mutex
+	start     time.Time
+	requester string
+	cache     string
+}
+
+// WithRequest returns a copy of the context for a single request, every line logged with it has the request id and route
+func (ctx RouteContext) WithRequest(id string, req *http.Request) RouteContext {
+	ctx.Logger = ctx.Logger.With(
+		slog.String("request_id", id),
+		slog.String("route", req.Pattern),
+		slog.String("user_agent", req.UserAgent()),
+	)
+	ctx.request = &requestLog{start: time.Now()}
+	return ctx
+}
+
+// WithRequester adds the authenticated user to the logger and the access log
+func (ctx RouteContext) WithRequester(requester string) RouteContext {
+	ctx.Logger = ctx.Logger.With(slog.String("requester", requester))
+	if ctx.request != nil {
+		ctx.request.mutex.Lock()
+		ctx.request.requester = requester
+		ctx.request.mutex.Unlock()
+	}
+	return ctx
+}
+
+// SetCacheStatus records whether the response was served from the cache, the last status of a request is logged
+func (ctx RouteContext) SetCacheStatus(status string) {
+	if ctx.request == nil {
+		return
+	}
+	ctx.request.mutex.Lock()
+	ctx.request.cache = status
+	ctx.request.mutex.Unlock()
+}
+
+// LogRequest writes the access log line of a request created with WithRequest
+func (ctx RouteContext) LogRequest(req *http.Request, status int) {
+	if ctx.request == nil {
+		return
+	}
+	ctx.request.mutex.Lock()
+	defer ctx.request.mutex.Unlock()
+
+	attributes := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("path", req.URL.Path),
+		slog.Int("status", status),
+		slog.Float64("latency_ms", float64(time.Since(ctx.request.start).Microseconds())/1000),
+	}
+	if ctx.request.requester != "" {
+		attributes = append(attributes, slog.String("requester", ctx.request.requester))
+	}
+	if ctx.request.cache != "" {
+		attributes = append(attributes, slog.String("cache", ctx.request.cache))
+	}
+
+	level := slog.LevelInfo
+	if status >= http.StatusInternalServerError {
+		level = slog.LevelWarn
+	}
+	ctx.Logger.LogAttrs(*ctx.Context, level, "request", attributes...)
+}
@@ -0,0 +1,270 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+const (
+	kindCounter   = "counter"
+	kindGauge     = "gauge"
+	kindHistogram = "histogram"
+)
+
+// DefaultBuckets are the upper bounds in seconds used for request latencies
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Registry holds the metrics written by the /metrics endpoint
+type Registry struct {
+	mutex    sync.Mutex
+	families map[string]*family
+}
+
+// Default is the registry the New functions register with
+var Default = NewRegistry()
+
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+func (registry *Registry) register(family *family) *family {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	if _, exists := registry.families[family.name]; exists {
+		panic(fmt.Sprintf("metric %s is registered twice", family.name))
+	}
+	registry.families[family.name] = family
+	// metrics without labels are reported as 0 before they are first updated
+	if len(family.labels) == 0 && family.collect == nil {
+		family.get(nil)
+	}
+	return family
+}
+
+// Write writes every metric in the Prometheus text format, sorted by name
+func (registry *Registry) Write(writer io.Writer) error {
+	registry.mutex.Lock()
+	families := make([]*family, 0, len(registry.families))
+	for _, family := range registry.families {
+		families = append(families, family)
+	}
+	registry.mutex.Unlock()
+	slices.SortFunc(families, func(a, b *family) int {
+		return strings.Compare(a.name, b.name)
+	})
+
+	buffered := bufio.NewWriter(writer)
+	for _, family := range families {
+		family.write(buffered)
+	}
+	return buffered.Flush()
+}
+
+// series is a single combination of label values, histograms count per bucket and not cumulative
+type series struct {
+	values []string
+	value  float64
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+type family struct {
+	name    string
+	help    string
+	kind    string
+	labels  []string
+	buckets []float64
+	mutex   sync.Mutex
+	series  map[string]*series
+	// collect replaces the stored series of metrics that are read when they are written
+	collect func(set func(value float64, values ...string))
+}
+
+func newFamily(name string, help string, kind string, labels []string) *family {
+	return &family{name: name, help: help, kind: kind, labels: labels, series: make(map[string]*series)}
+}
+
+// get returns the series of the label values, the caller holds the mutex
+func (family *family) get(values []string) *series {
+	if len(values) != len(family.labels) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", family.name, len(family.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	current, ok := family.series[key]
+	if !ok {
+		current = &series{values: slices.Clone(values)}
+		if family.kind == kindHistogram {
+			current.counts = make([]uint64, len(family.buckets))
+		}
+		family.series[key] = current
+	}
+	return current
+}
+
+func (family *family) snapshot() []series {
+	family.mutex.Lock()
+	defer family.mutex.Unlock()
+
+	if family.collect != nil {
+		family.series = make(map[string]*series)
+		family.collect(func(value float64, values ...string) {
+			family.get(values).value = value
+		})
+	}
+
+	snapshot := make([]series, 0, len(family.series))
+	for _, current := range family.series {
+		copied := *current
+		copied.counts = slices.Clone(current.counts)
+		snapshot = append(snapshot, copied)
+	}
+	slices.SortFunc(snapshot, func(a, b series) int {
+		return slices.Compare(a.values, b.values)
+	})
+	return snapshot
+}
+
+func (family *family) write(writer *bufio.Writer) {
+	snapshot := family.snapshot()
+	_, _ = fmt.Fprintf(writer, "# HELP %s %s\n", family.name, escape(family.help, false))
+	_, _ = fmt.Fprintf(writer, "# TYPE %s %s\n", family.name, family.kind)
+	for _, current := range snapshot {
+		if family.kind != kindHistogram {
+			family.writeSample(writer, family.name, current.values, "", current.value)
+			continue
+		}
+		cumulative := uint64(0)
+		for i, bound := range family.buckets {
+			cumulative += current.counts[i]
+			family.writeSample(writer, family.name+"_bucket", current.values, formatFloat(bound), float64(cumulative))
+		}
+		family.writeSample(writer, family.name+"_bucket", current.values, "+Inf", float64(current.count))
+		family.writeSample(writer, family.name+"_sum", current.values, "", current.sum)
+		family.writeSample(writer, family.name+"_count", current.values, "", float64(current.count))
+	}
+}
+
+// writeSample writes a single line, le is the bucket label of histograms
+func (family *family) writeSample(writer *bufio.Writer, name string, values []string, le string, value float64) {
+	_, _ = writer.WriteString(name)
+	if len(values) > 0 || le != "" {
+		_ = writer.WriteByte('{')
+		for i, label := range family.labels {
+			if i > 0 {
+				_ = writer.WriteByte(',')
+			}
+			_, _ = fmt.Fprintf(writer, `%s="%s"`, label, escape(values[i], true))
+		}
+		if le != "" {
+			if len(values) > 0 {
+				_ = writer.WriteByte(',')
+			}
+			_, _ = fmt.Fprintf(writer, `le="%s"`, le)
+		}
+		_ = writer.WriteByte('}')
+	}
+	_ = writer.WriteByte(' ')
+	_, _ = writer.WriteString(formatFloat(value))
+	_ = writer.WriteByte('\n')
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// escape escapes backslashes and newlines, quotes are only escaped in label values
+func escape(value string, quotes bool) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, "\n", `\n`)
+	if quotes {
+		value = strings.ReplaceAll(value, `"`, `\"`)
+	}
+	return value
+}
+
+// Counter only goes up, it is reset when the backend restarts
+type Counter struct {
+	family *family
+}
+
+func NewCounter(name string, help string, labels ...string) *Counter {
+	return &Counter{Default.register(newFamily(name, help, kindCounter, labels))}
+}
+
+func (counter *Counter) Inc(values ...string) {
+	counter.Add(1, values...)
+}
+
+func (counter *Counter) Add(value float64, values ...string) {
+	counter.family.mutex.Lock()
+	defer counter.family.mutex.Unlock()
+	counter.family.get(values).value += value
+}
+
+// Gauge is a value that can go up and down
+type Gauge struct {
+	family *family
+}
+
+func NewGauge(name string, help string, labels ...string) *Gauge {
+	return &Gauge{Default.register(newFamily(name, help, kindGauge, labels))}
+}
+
+func (gauge *Gauge) Set(value float64, values ...string) {
+	gauge.family.mutex.Lock()
+	defer gauge.family.mutex.Unlock()
+	gauge.family.get(values).value = value
+}
+
+// Histogram counts observations in buckets, buckets are upper bounds in ascending order
+type Histogram struct {
+	family *family
+}
+
+func NewHistogram(name string, help string, buckets []float64, labels ...string) *Histogram {
+	family := newFamily(name, help, kindHistogram, labels)
+	family.buckets = buckets
+	return &Histogram{Default.register(family)}
+}
+
+func (histogram *Histogram) Observe(value float64, values ...string) {
+	histogram.family.mutex.Lock()
+	defer histogram.family.mutex.Unlock()
+	current := histogram.family.get(values)
+	if i, _ := slices.BinarySearch(histogram.family.buckets, value); i < len(current.counts) {
+		current.counts[i]++
+	}
+	current.count++
+	current.sum += value
+}
+
+// NewGaugeFunc registers a gauge that is read when the metrics are written, collect calls set for every series
+func NewGaugeFunc(name string, help string, labels []string, collect func(set func(value float64, values ...string))) {
+	family := newFamily(name, help, kindGauge, labels)
+	family.collect = collect
+	Default.register(family)
+}
+
+// NewCounterFunc is NewGaugeFunc for values that only go up, e.g. totals kept by a library
+func NewCounterFunc(name string, help string, labels []string, collect func(set func(value float64, values ...string))) {
+	family := newFamily(name, help, kindCounter, labels)
+	family.collect = collect
+	Default.register(family)
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	registry := NewRegistry()
+	requests := &Counter{registry.register(newFamily("requests_total", "Handled requests", kindCounter, nil))}
+	keys := &Gauge{registry.register(newFamily("keys", "Api keys by state\nwith a \\ in the help", kindGauge, []string{"state"}))}
+	durationFamily := newFamily("duration_seconds", "Request duration", kindHistogram, []string{"route"})
+	durationFamily.buckets = []float64{0.25, 1, 2.5}
+	duration := &Histogram{registry.register(durationFamily)}
+
+	requests.Inc()
+	requests.Add(2)
+	keys.Set(3, "available")
+	keys.Set(-1, `quote " backslash \ newline`+"\n")
+	// bounds are inclusive and values above the last bound are only counted in +Inf
+	for _, value := range []float64{0.25, 0.5, 1, 3} {
+		duration.Observe(value, "/a")
+	}
+
+	expected := strings.Join([]string{
+		`# HELP duration_seconds Request duration`,
+		`# TYPE duration_seconds histogram`,
+		`duration_seconds_bucket{route="/a",le="0.25"} 1`,
+		`duration_seconds_bucket{route="/a",le="1"} 3`,
+		`duration_seconds_bucket{route="/a",le="2.5"} 3`,
+		`duration_seconds_bucket{route="/a",le="+Inf"} 4`,
+		`duration_seconds_sum{route="/a"} 4.75`,
+		`duration_seconds_count{route="/a"} 4`,
+		`# HELP keys Api keys by state\nwith a \\ in the help`,
+		`# TYPE keys gauge`,
+		`keys{state="available"} 3`,
+		`keys{state="quote \" backslash \\ newline\n"} -1`,
+		`# HELP requests_total Handled requests`,
+		`# TYPE requests_total counter`,
+		`requests_total 3`,
+		``,
+	}, "\n")
+
+	written := bytes.Buffer{}
+	if err := registry.Write(&written); err != nil {
+		t.Fatal(err)
+	}
+	if written.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, written.String())
+	}
+}
+
+func TestWriteUnusedMetrics(t *testing.T) {
+	registry := NewRegistry()
+	registry.register(newFamily("errors_total", "Errors", kindCounter, nil))
+	registry.register(newFamily("errors_by_route_total", "Errors by route", kindCounter, []string{"route"}))
+
+	// metrics without labels are 0, labelled ones have no series until they are used
+	expected := strings.Join([]string{
+		`# HELP errors_by_route_total Errors by route`,
+		`# TYPE errors_by_route_total counter`,
+		`# HELP errors_total Errors`,
+		`# TYPE errors_total counter`,
+		`errors_total 0`,
+		``,
+	}, "\n")
+
+	written := bytes.Buffer{}
+	if err := registry.Write(&written); err != nil {
+		t.Fatal(err)
+	}
+	if written.String() != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, written.String())
+	}
+}
@@ -0,0 +1,13 @@
+begin;
+
+create table if not exists auction_prices(
+    sb_id text not null,
+    recorded_at timestamptz not null,
+    lowest bigint not null,
+    highest bigint not null,
+    median bigint not null,
+    mean double precision not null,
+    constraint auction_prices_item_time primary key (sb_id, recorded_at)
+);
+
+commit;
\ No newline at end of file
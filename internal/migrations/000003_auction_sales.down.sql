@@ -0,0 +1,5 @@
+begin;
+
+drop table if exists auction_sales;
+
+commit;
\ No newline at end of file
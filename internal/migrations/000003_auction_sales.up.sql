@@ -0,0 +1,13 @@
+begin;
+
+create table if not exists auction_sales(
+    auction_id text not null primary key,
+    sb_id text not null,
+    price bigint not null,
+    sold_at timestamptz not null,
+    bin boolean not null
+);
+
+create index if not exists auction_sales_item_time on auction_sales(sb_id, sold_at);
+
+commit;
\ No newline at end of file
@@ -0,0 +1,5 @@
+begin;
+
+drop table if exists bazaar_prices;
+
+commit;
\ No newline at end of file
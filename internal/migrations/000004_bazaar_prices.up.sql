@@ -0,0 +1,15 @@
+begin;
+
+create table if not exists bazaar_prices(
+    product_id text not null,
+    recorded_at timestamptz not null,
+    buy_price double precision not null,
+    sell_price double precision not null,
+    buy_volume bigint not null,
+    sell_volume bigint not null,
+    buy_moving_week bigint not null,
+    sell_moving_week bigint not null,
+    constraint bazaar_prices_product_time primary key (product_id, recorded_at)
+);
+
+commit;
\ No newline at end of file
@@ -1,12 +1,21 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
 	"skyblock-pv-backend/auctions"
+	"skyblock-pv-backend/bazaar"
 	"skyblock-pv-backend/internal"
+	"skyblock-pv-backend/internal/metrics"
 	"skyblock-pv-backend/routes"
 	"skyblock-pv-backend/routes/handler"
+	"strconv"
+	"sync"
+	"syscall"
 	"time"
 )
 
@@ -48,46 +57,177 @@ func public(function func(internal.RouteContext, http.ResponseWriter, *http.Requ
 	return handler.PassthroughRequestHandler{Handler: function}
 }
 
-var routeContext = internal.NewRouteContext()
+var routeContext internal.RouteContext
+
+var (
+	requestCount = metrics.NewCounter(
+		"skyblock_http_requests_total",
+		"Handled requests by route, method and status",
+		"route", "method", "status",
+	)
+	requestDuration = metrics.NewHistogram(
+		"skyblock_http_request_duration_seconds",
+		"Duration of handled requests by route, method and status",
+		metrics.DefaultBuckets,
+		"route", "method", "status",
+	)
+)
+
+// statusRecorder keeps the status code written by a handler for the access log
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (recorder *statusRecorder) WriteHeader(status int) {
+	if recorder.status == 0 {
+		recorder.status = status
+	}
+	recorder.ResponseWriter.WriteHeader(status)
+}
+
+func (recorder *statusRecorder) Write(data []byte) (int, error) {
+	if recorder.status == 0 {
+		recorder.status = http.StatusOK
+	}
+	return recorder.ResponseWriter.Write(data)
+}
 
 func create(handlers RequestRoute) func(http.ResponseWriter, *http.Request) {
 	setDefaults(&handlers)
-	return func(res http.ResponseWriter, req *http.Request) {
+	return func(writer http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		id := internal.RequestId(req)
+		writer.Header().Set("X-Request-Id", id)
+		ctx := routeContext.WithRequest(id, req)
+		res := &statusRecorder{ResponseWriter: writer}
+		defer func() {
+			status := res.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			ctx.LogRequest(req, status)
+			requestCount.Inc(req.Pattern, req.Method, strconv.Itoa(status))
+			requestDuration.Observe(time.Since(start).Seconds(), req.Pattern, req.Method, strconv.Itoa(status))
+		}()
+
 		switch req.Method {
 		case "GET":
-			handlers.Get.Handle(routeContext, res, req)
+			handlers.Get.Handle(ctx, res, req)
 		case "POST":
-			handlers.Post.Handle(routeContext, res, req)
+			handlers.Post.Handle(ctx, res, req)
 		case "PUT":
-			handlers.Put.Handle(routeContext, res, req)
+			handlers.Put.Handle(ctx, res, req)
 		case "DELETE":
-			handlers.Delete.Handle(routeContext, res, req)
+			handlers.Delete.Handle(ctx, res, req)
 		default:
 			res.WriteHeader(http.StatusMethodNotAllowed)
 		}
 	}
 }
 
-func fetchData() {
+func fetchData(ctx context.Context) {
 	err := auctions.FetchAll(&routeContext)
-	if err != nil {
+	if err != nil && ctx.Err() == nil {
 		panic(err) // panic because we just started
 	}
 	updateData := time.NewTicker(time.Hour)
+	defer updateData.Stop()
+	refreshData := time.NewTicker(time.Minute)
+	defer refreshData.Stop()
 	for {
 		select {
+		case <-ctx.Done():
+			return
 		case <-updateData.C:
 			err = auctions.FetchAll(&routeContext)
-			if err != nil {
-				fmt.Printf("Error fetching auctions: %v\n", err)
-				fmt.Print("Trying again later, using current data.")
+			if err != nil && ctx.Err() == nil {
+				routeContext.Logger.Error("Failed to fetch auctions, using current data until the next fetch", "err", err)
+			}
+		case <-refreshData.C:
+			err = auctions.Refresh(&routeContext)
+			if err != nil && ctx.Err() == nil {
+				routeContext.Logger.Error("Failed to refresh auctions", "err", err)
 			}
 		}
 	}
 }
 
+func fetchBazaar(ctx context.Context) {
+	refreshBazaar := time.NewTicker(time.Minute)
+	defer refreshBazaar.Stop()
+	for {
+		if err := bazaar.Fetch(&routeContext); err != nil && ctx.Err() == nil {
+			routeContext.Logger.Error("Failed to fetch bazaar", "err", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-refreshBazaar.C:
+		}
+	}
+}
+
+func milliseconds(milli int) time.Duration {
+	return time.Duration(milli) * time.Millisecond
+}
+
+// serve runs the server until it fails or the process is asked to stop, then shuts down in order: the server drains
+// in-flight requests, background jobs are cancelled and awaited, and redis and postgres are closed last
+func serve(server *http.Server, cancelJobs context.CancelFunc, jobs *sync.WaitGroup) error {
+	stop, release := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer release()
+
+	failed := make(chan error, 1)
+	go func() {
+		failed <- server.ListenAndServe()
+	}()
+
+	var serverErr error
+	select {
+	case serverErr = <-failed:
+		routeContext.Logger.Error("Server stopped unexpectedly", "err", serverErr)
+	case <-stop.Done():
+		routeContext.Logger.Info("Shutting down")
+	}
+
+	shutdown, cancel := context.WithTimeout(context.Background(), milliseconds(routeContext.Config.Server.ShutdownTimeoutMilli))
+	defer cancel()
+	if err := server.Shutdown(shutdown); err != nil {
+		routeContext.Logger.Error("Failed to drain connections", "err", err)
+	}
+
+	cancelJobs()
+	finished := make(chan struct{})
+	go func() {
+		jobs.Wait()
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-shutdown.Done():
+		routeContext.Logger.Warn("Background jobs did not finish in time")
+	}
+
+	routeContext.Close()
+	routeContext.Logger.Info("Shut down")
+	return serverErr
+}
+
 func main() {
-	go fetchData()
+	// the background context outlives the signal, requests that are drained on shutdown still use it
+	background, cancelJobs := context.WithCancel(context.Background())
+	defer cancelJobs()
+	routeContext = internal.NewRouteContext(background)
+
+	jobs := sync.WaitGroup{}
+	jobs.Go(func() {
+		fetchData(background)
+	})
+	jobs.Go(func() {
+		fetchBazaar(background)
+	})
+
 	http.HandleFunc("/authenticate", create(RequestRoute{
 		Get: public(routes.Authenticate),
 	}))
@@ -106,6 +246,9 @@ func main() {
 	http.HandleFunc("/guild/{id}", create(RequestRoute{
 		Get: private(routes.GetGuild),
 	}))
+	http.HandleFunc("/networth/{player}/{profile}", create(RequestRoute{
+		Get: private(routes.GetNetworth),
+	}))
 	http.HandleFunc("/auctions/{profile}", create(RequestRoute{
 		Get: private(routes.GetActiveProfileAuctions),
 	}))
@@ -116,6 +259,33 @@ func main() {
 	http.HandleFunc("/auctions", create(RequestRoute{
 		Get: public(routes.GetLbin),
 	}))
+	http.HandleFunc("/auctions/search", create(RequestRoute{
+		Get: public(routes.GetAuctionSearch),
+	}))
+	http.HandleFunc("/auctions/item/{sb_id}", create(RequestRoute{
+		Get: public(routes.GetItemLbin),
+	}))
+	http.HandleFunc("/auctions/history/{sb_id}", create(RequestRoute{
+		Get: public(routes.GetAuctionHistory),
+	}))
+	http.HandleFunc("/bazaar", create(RequestRoute{
+		Get: public(routes.GetBazaar),
+	}))
+	http.HandleFunc("/bazaar/history/{id}", create(RequestRoute{
+		Get: public(routes.GetBazaarHistory),
+	}))
+	http.HandleFunc("/prices", create(RequestRoute{
+		Get: public(routes.GetPrices),
+	}))
+	http.HandleFunc("/nbt", create(RequestRoute{
+		Post: authenticated(routes.PostNbt),
+	}))
+	http.HandleFunc("/lore", create(RequestRoute{
+		Post: authenticated(routes.PostLore),
+	}))
+	http.HandleFunc("/value", create(RequestRoute{
+		Post: public(routes.PostValue),
+	}))
 	http.HandleFunc("/shared_data/{player_id}", create(RequestRoute{
 		Get: private(routes.GetSharedData),
 	}))
@@ -140,14 +310,37 @@ func main() {
 	registerUserData("time_pocket", routes.PutTimePocket)
 	registerUserData("garden_chips", routes.PutGardenChips)
 
+	http.HandleFunc("/healthz", create(RequestRoute{
+		Get: public(routes.GetHealth),
+	}))
+	http.HandleFunc("/readyz", create(RequestRoute{
+		Get: public(routes.GetReadiness),
+	}))
+	http.HandleFunc("/version", create(RequestRoute{
+		Get: public(routes.GetVersion),
+	}))
+	http.HandleFunc("/metrics", create(RequestRoute{
+		Get: public(routes.GetMetrics),
+	}))
 	http.HandleFunc("/_ratelimit", create(RequestRoute{
 		Get: admin(routes.GetRateLimit),
 	}))
+	http.HandleFunc("/_auctions", create(RequestRoute{
+		Get: admin(routes.GetAuctionStats),
+	}))
 
-	fmt.Printf("Listening on 0.0.0.0:%s\n", routeContext.Config.Port)
-	err := http.ListenAndServe(fmt.Sprintf(":%s", routeContext.Config.Port), nil)
+	config := routeContext.Config.Server
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%s", routeContext.Config.Port),
+		ReadTimeout:       milliseconds(config.ReadTimeoutMilli),
+		ReadHeaderTimeout: milliseconds(config.ReadHeaderTimeoutMilli),
+		WriteTimeout:      milliseconds(config.WriteTimeoutMilli),
+		IdleTimeout:       milliseconds(config.IdleTimeoutMilli),
+		ErrorLog:          slog.NewLogLogger(routeContext.Logger.Handler(), slog.LevelWarn),
+	}
 
-	if err != nil {
-		panic(err)
+	routeContext.Logger.Info("Listening", "address", "0.0.0.0:"+routeContext.Config.Port)
+	if err := serve(server, cancelJobs, &jobs); err != nil {
+		os.Exit(1)
 	}
 }
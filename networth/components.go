@@ -0,0 +1,137 @@
+package networth
+
+import (
+	"fmt"
+	"skyblock-pv-backend/utils"
+	"strings"
+)
+
+// share of the price an upgrade adds to the item, applying most upgrades can't be undone so they are worth less
+const (
+	enchantmentWorth      = 0.85
+	recombobulatorWorth   = 0.8
+	hotPotatoBookWorth    = 1
+	fumingPotatoBookWorth = 0.6
+	gemstoneWorth         = 1
+	// pets that used candy are worth less until they are max level
+	petCandyReduction = 0.35
+)
+
+// books past this amount are fuming potato books
+const maxHotPotatoBooks = 10
+
+type itemValuer struct {
+	prices     PriceSource
+	item       utils.Item
+	sbId       string
+	components []Component
+}
+
+func (valuer *itemValuer) add(componentType string, id string, count int, worth float64) {
+	price, ok := valuer.prices.Price(id)
+	if !ok || count <= 0 {
+		return
+	}
+	valuer.components = append(valuer.components, Component{
+		Type:  componentType,
+		Id:    id,
+		Count: count,
+		Price: price,
+		Value: int64(float64(price) * float64(count) * worth),
+	})
+}
+
+func (valuer *itemValuer) value(count int) *ItemValue {
+	value := &ItemValue{Id: valuer.sbId, Count: count, Components: valuer.components}
+	for _, component := range valuer.components {
+		value.Value += component.Value
+	}
+	return value
+}
+
+func (valuer *itemValuer) addBase() {
+	if pet := valuer.item.GetPetData(); pet != nil {
+		valuer.addPet(pet)
+		return
+	}
+	// books with multiple enchantments are only worth their enchantments, see addEnchantments
+	if valuer.sbId == "ENCHANTED_BOOK" {
+		return
+	}
+	valuer.add(ComponentBase, valuer.sbId, max(valuer.item.Count(), 1), 1)
+}
+
+// addPet interpolates between the level 1 and max level price by exp, the price of the exact level is used if known
+func (valuer *itemValuer) addPet(pet *utils.PetData) {
+	level := pet.Level()
+	exact := valuer.sbId + utils.FingerprintPart("pet_level", fmt.Sprintf("%d", level))
+	lowest := valuer.sbId + utils.FingerprintPart("pet_level", "1")
+	highest := valuer.sbId + utils.FingerprintPart("pet_level", fmt.Sprintf("%d", utils.MaxPetLevel))
+
+	if _, ok := valuer.prices.Price(exact); ok {
+		valuer.add(ComponentBase, exact, 1, 1)
+	} else {
+		lowestPrice, hasLowest := valuer.prices.Price(lowest)
+		highestPrice, hasHighest := valuer.prices.Price(highest)
+		if !hasLowest || !hasHighest || highestPrice <= lowestPrice {
+			valuer.add(ComponentBase, valuer.sbId, 1, 1)
+		} else {
+			valuer.add(ComponentBase, lowest, 1, 1)
+			progress := min(pet.Exp/pet.MaxExp(), 1)
+			valuer.components = append(valuer.components, Component{
+				Type:  ComponentPetLevel,
+				Id:    exact,
+				Count: 1,
+				Price: highestPrice - lowestPrice,
+				Value: int64(float64(highestPrice-lowestPrice) * progress),
+			})
+		}
+	}
+
+	if pet.CandiesUsed > 0 && level < utils.MaxPetLevel && len(valuer.components) > 0 {
+		total := int64(0)
+		for _, component := range valuer.components {
+			total += component.Value
+		}
+		valuer.components = append(valuer.components, Component{
+			Type:  ComponentPetCandy,
+			Id:    "PET_CANDY",
+			Count: int(pet.CandiesUsed),
+			Price: 0,
+			Value: -int64(float64(total) * petCandyReduction),
+		})
+	}
+}
+
+func (valuer *itemValuer) addEnchantments() {
+	// single enchanted books are already priced by their enchantment
+	if strings.HasPrefix(valuer.sbId, "enchantment:") {
+		return
+	}
+	worth := enchantmentWorth
+	if id, _ := utils.IdPath.GetString(valuer.item.Attributes()); id == "ENCHANTED_BOOK" {
+		worth = 1
+	}
+	for _, enchantment := range valuer.item.GetEnchantments() {
+		valuer.add(ComponentEnchantment, fmt.Sprintf("enchantment:%s:%d", enchantment.Name, enchantment.Level), 1, worth)
+	}
+}
+
+func (valuer *itemValuer) addRecombobulator() {
+	if upgrades, _ := utils.RarityUpgradesPath.GetInteger(valuer.item.Attributes()); upgrades > 0 {
+		valuer.add(ComponentRecombobulator, "RECOMBOBULATOR_3000", 1, recombobulatorWorth)
+	}
+}
+
+func (valuer *itemValuer) addPotatoBooks() {
+	count, _ := utils.HotPotatoCountPath.GetInteger(valuer.item.Attributes())
+	books := int(count)
+	valuer.add(ComponentHotPotatoBook, "HOT_POTATO_BOOK", min(books, maxHotPotatoBooks), hotPotatoBookWorth)
+	valuer.add(ComponentFumingPotatoBook, "FUMING_POTATO_BOOK", books-maxHotPotatoBooks, fumingPotatoBookWorth)
+}
+
+func (valuer *itemValuer) addGemstones() {
+	for _, gemstone := range valuer.item.GetGemstones() {
+		valuer.add(ComponentGemstone, fmt.Sprintf("%s_%s_GEM", gemstone.Quality, gemstone.Type), 1, gemstoneWorth)
+	}
+}
@@ -0,0 +1,113 @@
+package networth
+
+import (
+	"fmt"
+	"skyblock-pv-backend/utils"
+)
+
+// PriceSource provides the price of one item by its sb id or fingerprint, auctions.PriceIndex and bazaar.Index implement it
+type PriceSource interface {
+	Price(id string) (int64, bool)
+}
+
+// PriceSources asks the sources in order and returns the first price, e.g. the bazaar before the auctions
+type PriceSources []PriceSource
+
+func (sources PriceSources) Price(id string) (int64, bool) {
+	for _, source := range sources {
+		if price, ok := source.Price(id); ok {
+			return price, true
+		}
+	}
+	return 0, false
+}
+
+const (
+	ComponentBase             = "base"
+	ComponentEnchantment      = "enchantment"
+	ComponentRecombobulator   = "recombobulator"
+	ComponentHotPotatoBook    = "hot_potato_book"
+	ComponentFumingPotatoBook = "fuming_potato_book"
+	ComponentGemstone         = "gemstone"
+	ComponentPetLevel         = "pet_level"
+	ComponentPetCandy         = "pet_candy"
+)
+
+// Component is one part of the value of an item, Price is the price of a single Id before applying the worth
+type Component struct {
+	Type  string `json:"type"`
+	Id    string `json:"id"`
+	Count int    `json:"count"`
+	Price int64  `json:"price"`
+	Value int64  `json:"value"`
+}
+
+type ItemValue struct {
+	Id         string      `json:"id"`
+	Count      int         `json:"count"`
+	Value      int64       `json:"value"`
+	Components []Component `json:"components"`
+}
+
+type InventoryValue struct {
+	Value int64       `json:"value"`
+	Items []ItemValue `json:"items"`
+	// Error is set if some inventories of the category couldn't be decoded, the value only covers the others
+	Error string `json:"error,omitempty"`
+}
+
+// ValueItem prices the item and all upgrades applied to it, returns nil for items without sb id
+func ValueItem(prices PriceSource, item utils.Item) *ItemValue {
+	sbId := item.GetSbId()
+	if sbId == nil {
+		return nil
+	}
+
+	valuer := itemValuer{prices: prices, item: item, sbId: *sbId, components: make([]Component, 0)}
+	valuer.addBase()
+	valuer.addEnchantments()
+	valuer.addRecombobulator()
+	valuer.addPotatoBooks()
+	valuer.addGemstones()
+
+	return valuer.value(max(item.Count(), 1))
+}
+
+// ValueInventory prices every item of a base64 encoded inventory, items without a price are listed with a value of 0
+func ValueInventory(prices PriceSource, data string) (*InventoryValue, error) {
+	items, err := utils.DecodeInventory(data)
+	if err != nil {
+		return nil, err
+	}
+	return ValueItems(prices, items), nil
+}
+
+// ValuePet prices a pet of the pets menu, these aren't items so only the pet itself is priced
+func ValuePet(prices PriceSource, pet utils.PetData) *ItemValue {
+	valuer := itemValuer{prices: prices, sbId: fmt.Sprintf("pet:%s:%s", pet.Type, pet.Tier), components: make([]Component, 0)}
+	valuer.addPet(&pet)
+	return valuer.value(1)
+}
+
+// ValueStack prices an amount of an item without attributes, e.g. the contents of sacks
+func ValueStack(prices PriceSource, id string, count int) *ItemValue {
+	valuer := itemValuer{prices: prices, sbId: id, components: make([]Component, 0)}
+	valuer.add(ComponentBase, id, count, 1)
+	return valuer.value(count)
+}
+
+func ValueItems(prices PriceSource, items []utils.Item) *InventoryValue {
+	inventory := &InventoryValue{Items: make([]ItemValue, 0, len(items))}
+	for _, item := range items {
+		inventory.add(ValueItem(prices, item))
+	}
+	return inventory
+}
+
+func (inventory *InventoryValue) add(value *ItemValue) {
+	if value == nil {
+		return
+	}
+	inventory.Value += value.Value
+	inventory.Items = append(inventory.Items, *value)
+}
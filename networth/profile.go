@@ -0,0 +1,189 @@
+package networth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"skyblock-pv-backend/utils"
+	"strings"
+)
+
+var ErrProfileNotFound = errors.New("profile not found")
+
+const (
+	CategoryInventory   = "inventory"
+	CategoryArmor       = "armor"
+	CategoryEquipment   = "equipment"
+	CategoryEnderChest  = "ender_chest"
+	CategoryBackpacks   = "backpacks"
+	CategoryWardrobe    = "wardrobe"
+	CategoryAccessories = "accessories"
+	CategoryPets        = "pets"
+	CategorySacks       = "sacks"
+)
+
+type ProfileValue struct {
+	ProfileId string                 `json:"profile_id"`
+	Value     int64                  `json:"value"`
+	Bank      int64                  `json:"bank"`
+	Members   map[string]MemberValue `json:"members"`
+	// Failures are the inventories that couldn't be decoded, their categories are marked in the response
+	Failures []DecodeFailure `json:"-"`
+}
+
+// DecodeFailure is an inventory blob that couldn't be decoded, the rest of the profile is still valued
+type DecodeFailure struct {
+	Member   string
+	Category string
+	Data     string
+	Err      error
+}
+
+type MemberValue struct {
+	Value      int64                      `json:"value"`
+	Purse      int64                      `json:"purse"`
+	Categories map[string]*InventoryValue `json:"categories"`
+}
+
+type profilesData struct {
+	Profiles []profileData `json:"profiles"`
+}
+
+type profileData struct {
+	ProfileId string                `json:"profile_id"`
+	Members   map[string]memberData `json:"members"`
+	Banking   struct {
+		Balance float64 `json:"balance"`
+	} `json:"banking"`
+}
+
+type inventoryData struct {
+	Data string `json:"data"`
+}
+
+type memberData struct {
+	Inventory struct {
+		Contents    inventoryData            `json:"inv_contents"`
+		Armor       inventoryData            `json:"inv_armor"`
+		Equipment   inventoryData            `json:"equipment_contents"`
+		EnderChest  inventoryData            `json:"ender_chest_contents"`
+		Backpacks   map[string]inventoryData `json:"backpack_contents"`
+		Wardrobe    inventoryData            `json:"wardrobe_contents"`
+		Accessories struct {
+			TalismanBag inventoryData `json:"talisman_bag"`
+		} `json:"bag_contents"`
+		Sacks map[string]int `json:"sacks_counts"`
+	} `json:"inventory"`
+	Pets struct {
+		Pets []utils.PetData `json:"pets"`
+	} `json:"pets_data"`
+	Currencies struct {
+		CoinPurse float64 `json:"coin_purse"`
+	} `json:"currencies"`
+}
+
+// ValueProfile prices every member of a profile from the /v2/skyblock/profiles response,
+// inventories that are missing (e.g. because the inventory api is disabled) are left out and inventories that can't be
+// decoded are returned in Failures
+func ValueProfile(prices PriceSource, profiles string, profileId string) (*ProfileValue, error) {
+	var data profilesData
+	if err := json.Unmarshal([]byte(profiles), &data); err != nil {
+		return nil, err
+	}
+
+	for _, profile := range data.Profiles {
+		if normalizeId(profile.ProfileId) != normalizeId(profileId) {
+			continue
+		}
+
+		value := &ProfileValue{
+			ProfileId: profile.ProfileId,
+			Bank:      int64(profile.Banking.Balance),
+			Members:   make(map[string]MemberValue, len(profile.Members)),
+		}
+		value.Value = value.Bank
+		for id, member := range profile.Members {
+			memberValue, failures := valueMember(prices, member)
+			for _, failure := range failures {
+				failure.Member = id
+				value.Failures = append(value.Failures, failure)
+			}
+			value.Members[id] = *memberValue
+			value.Value += memberValue.Value
+		}
+		return value, nil
+	}
+	return nil, ErrProfileNotFound
+}
+
+// valueMember values every inventory that can be decoded, categories with inventories that can't be decoded are
+// marked with an error and returned as failures
+func valueMember(prices PriceSource, member memberData) (*MemberValue, []DecodeFailure) {
+	inventory := member.Inventory
+	value := &MemberValue{Purse: int64(member.Currencies.CoinPurse), Categories: make(map[string]*InventoryValue)}
+
+	blobs := map[string][]inventoryData{
+		CategoryInventory:   {inventory.Contents},
+		CategoryArmor:       {inventory.Armor},
+		CategoryEquipment:   {inventory.Equipment},
+		CategoryEnderChest:  {inventory.EnderChest},
+		CategoryWardrobe:    {inventory.Wardrobe},
+		CategoryAccessories: {inventory.Accessories.TalismanBag},
+	}
+	for _, backpack := range inventory.Backpacks {
+		blobs[CategoryBackpacks] = append(blobs[CategoryBackpacks], backpack)
+	}
+
+	failures := make([]DecodeFailure, 0)
+	for category, inventories := range blobs {
+		items := make([]utils.Item, 0)
+		var failure error
+		for _, inventory := range inventories {
+			if inventory.Data == "" {
+				continue
+			}
+			decoded, err := utils.DecodeInventory(inventory.Data)
+			if err != nil {
+				failure = err
+				failures = append(failures, DecodeFailure{Category: category, Data: inventory.Data, Err: err})
+				continue
+			}
+			items = append(items, decoded...)
+		}
+		if len(items) > 0 || failure != nil {
+			categoryValue := ValueItems(prices, items)
+			if failure != nil {
+				categoryValue.Error = fmt.Sprintf("failed to decode %s: %v", category, failure)
+			}
+			value.Categories[category] = categoryValue
+		}
+	}
+
+	if len(member.Pets.Pets) > 0 {
+		pets := &InventoryValue{Items: make([]ItemValue, 0, len(member.Pets.Pets))}
+		for _, pet := range member.Pets.Pets {
+			pets.add(ValuePet(prices, pet))
+		}
+		value.Categories[CategoryPets] = pets
+	}
+
+	if len(inventory.Sacks) > 0 {
+		sacks := &InventoryValue{Items: make([]ItemValue, 0, len(inventory.Sacks))}
+		for id, count := range inventory.Sacks {
+			if count > 0 {
+				sacks.add(ValueStack(prices, id, count))
+			}
+		}
+		value.Categories[CategorySacks] = sacks
+	}
+
+	value.Value = value.Purse
+	for _, category := range value.Categories {
+		value.Value += category.Value
+	}
+	return value, failures
+}
+
+func normalizeId(id string) string {
+	return strings.ToLower(strings.ReplaceAll(id, "-", ""))
+}
@@ -0,0 +1,98 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"skyblock-pv-backend/auctions"
+	"skyblock-pv-backend/internal"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const auctionHistoryCacheDuration = 5 * time.Minute
+const defaultHistoryWindow = 7 * 24 * time.Hour
+const maxHistoryWindow = 2 * 365 * 24 * time.Hour
+
+// GetAuctionHistory returns the hourly price snapshots of an item, query parameters:
+// from/to as unix milliseconds or window as a duration (e.g. 36h or 30d), interval as hourly, daily or weekly
+func GetAuctionHistory(ctx internal.RouteContext, res http.ResponseWriter, req *http.Request) {
+	sbId := req.PathValue("sb_id")
+	query := req.URL.Query()
+
+	interval := query.Get("interval")
+	if interval == "" {
+		interval = "hourly"
+	}
+	if _, ok := auctions.HistoryIntervals[interval]; !ok {
+		res.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	from, to, err := parseHistoryWindow(query.Get("from"), query.Get("to"), query.Get("window"))
+	if err != nil || !from.Before(to) || to.Sub(from) > maxHistoryWindow {
+		res.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	points, err := auctions.GetHistory(&ctx, sbId, from, to, interval)
+	if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		ctx.Logger.Error("Failed to fetch price history", "sb_id", sbId, "err", err)
+		return
+	}
+
+	data, err := json.Marshal(points)
+	if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		ctx.Logger.Error("Failed to encode price history", "sb_id", sbId, "err", err)
+		return
+	}
+
+	res.Header().Set("X-Auction-Version", fmt.Sprintf("v%d", auctions.AuthCacheVersion))
+	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", int(auctionHistoryCacheDuration.Seconds())))
+	_, _ = res.Write(data)
+}
+
+func parseHistoryWindow(fromValue string, toValue string, windowValue string) (time.Time, time.Time, error) {
+	to := time.Now()
+	if toValue != "" {
+		milli, err := strconv.ParseInt(toValue, 10, 64)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		to = time.UnixMilli(milli)
+	}
+
+	if fromValue != "" {
+		milli, err := strconv.ParseInt(fromValue, 10, 64)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		return time.UnixMilli(milli), to, nil
+	}
+
+	window := defaultHistoryWindow
+	if windowValue != "" {
+		var err error
+		window, err = parseWindow(windowValue)
+		if err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+	}
+	return to.Add(-window), to, nil
+}
+
+// parseWindow parses a go duration, additionally allowing days (e.g. 30d)
+func parseWindow(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		amount, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(amount) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
@@ -1,20 +1,136 @@
 package routes
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"skyblock-pv-backend/auctions"
 	"skyblock-pv-backend/internal"
+	"strings"
 )
 
-func GetLbin(ctx internal.RouteContext, res http.ResponseWriter, _ *http.Request) {
-	cachedData, err := auctions.GetCachedAuctions(&ctx)
+// GetLbin returns the price snapshot, optionally filtered with the query parameters
+// ids (comma separated), prefix (e.g. pet: or rune:) and fields (comma separated, e.g. lowest).
+// variants are keyed by their fingerprint, prefix=HYPERION; returns every variant of an item
+func GetLbin(ctx internal.RouteContext, res http.ResponseWriter, req *http.Request) {
+	index, err := auctions.GetPriceIndex(&ctx)
 	if err != nil {
 		res.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+
+	query := req.URL.Query()
+	if !query.Has("ids") && !query.Has("prefix") && !query.Has("fields") {
+		writeAuctionResponse(res, index.Raw())
+		return
+	}
+
+	fields, ok := parseFields(query.Get("fields"))
+	if !ok {
+		res.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var items map[string]auctions.ItemInfo
+	if query.Has("ids") {
+		items = index.Select(strings.Split(query.Get("ids"), ","))
+		if prefix := query.Get("prefix"); prefix != "" {
+			for id := range items {
+				if !strings.HasPrefix(id, prefix) {
+					delete(items, id)
+				}
+			}
+		}
+	} else {
+		items = index.WithPrefix(query.Get("prefix"))
+	}
+
+	projected := make(map[string]map[string]interface{}, len(items))
+	for id, info := range items {
+		projected[id] = projectItemInfo(info, fields)
+	}
+
+	data, err := json.Marshal(projected)
+	if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		ctx.Logger.Error("Failed to encode auctions", "err", err)
+		return
+	}
+	writeAuctionResponse(res, string(data))
+}
+
+func GetItemLbin(ctx internal.RouteContext, res http.ResponseWriter, req *http.Request) {
+	index, err := auctions.GetPriceIndex(&ctx)
+	if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	fields, ok := parseFields(req.URL.Query().Get("fields"))
+	if !ok {
+		res.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	info, ok := index.Get(req.PathValue("sb_id"))
+	if !ok {
+		res.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	data, err := json.Marshal(projectItemInfo(info, fields))
+	if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		ctx.Logger.Error("Failed to encode auction", "err", err)
+		return
+	}
+	writeAuctionResponse(res, string(data))
+}
+
+func writeAuctionResponse(res http.ResponseWriter, data string) {
 	res.Header().Set("X-Auction-Version", fmt.Sprintf("v%d", auctions.AuthCacheVersion))
 	res.Header().Set("Content-Type", "application/json")
-	_, _ = io.WriteString(res, *cachedData)
+	_, _ = io.WriteString(res, data)
+}
+
+var itemInfoFields = []string{"lowest", "highest", "median", "mean", "rarity", "sold"}
+
+func parseFields(value string) ([]string, bool) {
+	if value == "" {
+		return itemInfoFields, true
+	}
+	fields := strings.Split(value, ",")
+	for _, field := range fields {
+		if projectItemField(auctions.ItemInfo{}, field) == nil {
+			return nil, false
+		}
+	}
+	return fields, true
+}
+
+func projectItemInfo(info auctions.ItemInfo, fields []string) map[string]interface{} {
+	projected := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		projected[field] = projectItemField(info, field)
+	}
+	return projected
+}
+
+func projectItemField(info auctions.ItemInfo, field string) interface{} {
+	switch field {
+	case "lowest":
+		return info.Lowest
+	case "highest":
+		return info.Highest
+	case "median":
+		return info.Median
+	case "mean":
+		return info.Mean
+	case "rarity":
+		return info.Rarity
+	case "sold":
+		return info.Sold
+	}
+	return nil
 }
@@ -0,0 +1,87 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"skyblock-pv-backend/auctions"
+	"skyblock-pv-backend/internal"
+	"strconv"
+)
+
+// GetAuctionSearch searches the active auctions, query parameters (all optional):
+// sb_id (sb id or fingerprint), name (substring), rarity, bin (true or false), min_price and max_price, seller (uuid),
+// attribute (repeatable, e.g. modifier=heroic or enchantments.ultimate_wise>=4), sort (price_asc, price_desc,
+// ending_soon or newest), limit (up to 100) and cursor (next of the previous page)
+func GetAuctionSearch(ctx internal.RouteContext, res http.ResponseWriter, req *http.Request) {
+	query, err := parseSearchQuery(req)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	result, err := auctions.Search(*query)
+	if errors.Is(err, auctions.ErrSearchUnavailable) {
+		res.WriteHeader(http.StatusServiceUnavailable)
+		return
+	} else if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		ctx.Logger.Error("Failed to encode search result", "err", err)
+		return
+	}
+	res.Header().Set("X-Auction-Version", fmt.Sprintf("v%d", auctions.AuthCacheVersion))
+	res.Header().Set("Content-Type", "application/json")
+	_, _ = res.Write(data)
+}
+
+func parseSearchQuery(req *http.Request) (*auctions.SearchQuery, error) {
+	values := req.URL.Query()
+	query := &auctions.SearchQuery{
+		SbId:   values.Get("sb_id"),
+		Name:   values.Get("name"),
+		Rarity: values.Get("rarity"),
+		Seller: values.Get("seller"),
+		Sort:   values.Get("sort"),
+		Cursor: values.Get("cursor"),
+	}
+
+	if values.Has("bin") {
+		bin, err := strconv.ParseBool(values.Get("bin"))
+		if err != nil {
+			return nil, err
+		}
+		query.Bin = &bin
+	}
+	for name, target := range map[string]*int64{"min_price": &query.MinPrice, "max_price": &query.MaxPrice} {
+		if !values.Has(name) {
+			continue
+		}
+		value, err := strconv.ParseInt(values.Get(name), 10, 64)
+		if err != nil || value < 0 {
+			return nil, fmt.Errorf("invalid %s", name)
+		}
+		*target = value
+	}
+	if values.Has("limit") {
+		limit, err := strconv.Atoi(values.Get("limit"))
+		if err != nil || limit <= 0 || limit > auctions.MaxSearchLimit {
+			return nil, fmt.Errorf("invalid limit")
+		}
+		query.Limit = limit
+	}
+	for _, value := range values["attribute"] {
+		filter, err := auctions.ParseAttributeFilter(value)
+		if err != nil {
+			return nil, err
+		}
+		query.Attributes = append(query.Attributes, *filter)
+	}
+	return query, nil
+}
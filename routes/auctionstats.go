@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"skyblock-pv-backend/auctions"
+	"skyblock-pv-backend/internal"
+)
+
+// GetAuctionStats returns timing and failure stats of the last full and incremental auction refresh
+func GetAuctionStats(_ internal.RouteContext, _ internal.AuthenticationContext, res http.ResponseWriter, _ *http.Request) {
+	res.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(res).Encode(auctions.LastRuns())
+}
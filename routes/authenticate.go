@@ -35,7 +35,7 @@ func Authenticate(ctx internal.RouteContext, res http.ResponseWriter, req *http.
 
 		if err != nil {
 			res.WriteHeader(http.StatusInternalServerError)
-			fmt.Printf("Authentication failed for user '%s' with error: %v\n", username, err)
+			ctx.Logger.Error("Authentication failed", "username", username, "err", err)
 			return
 		}
 
@@ -43,7 +43,7 @@ func Authenticate(ctx internal.RouteContext, res http.ResponseWriter, req *http.
 
 		if r.StatusCode != http.StatusOK {
 			res.WriteHeader(http.StatusUnauthorized)
-			fmt.Printf("Authentication failed for user '%s' with status code %d\n", username, r.StatusCode)
+			ctx.Logger.Info("Authentication failed", "username", username, "mojang_status", r.StatusCode)
 			return
 		}
 
@@ -53,13 +53,13 @@ func Authenticate(ctx internal.RouteContext, res http.ResponseWriter, req *http.
 
 		if err != nil {
 			res.WriteHeader(http.StatusInternalServerError)
-			fmt.Printf("Failed to decode session response: %v\n", err)
+			ctx.Logger.Error("Failed to decode session response", "err", err)
 		} else {
 			bypassCache := req.URL.Query().Has("bypassCache") && slices.Contains(ctx.Config.Admins, session.Id)
 			token, err := internal.CreateAuthenticationKey(ctx, session.Id, bypassCache)
 			if err != nil {
 				res.WriteHeader(http.StatusInternalServerError)
-				fmt.Printf("Failed to create authentication key: %v\n", err)
+				ctx.Logger.Error("Failed to create authentication key", "err", err)
 			} else {
 				_, _ = io.WriteString(res, token)
 			}
@@ -68,7 +68,7 @@ func Authenticate(ctx internal.RouteContext, res http.ResponseWriter, req *http.
 		token, err := internal.CreateGuestAuthenticationKey(ctx, false)
 		if err != nil {
 			res.WriteHeader(http.StatusInternalServerError)
-			fmt.Printf("Failed to create authentication key: %v\n", err)
+			ctx.Logger.Error("Failed to create authentication key", "err", err)
 		} else {
 			_, _ = io.WriteString(res, token)
 		}
@@ -0,0 +1,66 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"skyblock-pv-backend/bazaar"
+	"skyblock-pv-backend/internal"
+)
+
+// GetBazaar returns the bazaar snapshot keyed by sb id
+func GetBazaar(ctx internal.RouteContext, res http.ResponseWriter, _ *http.Request) {
+	index, err := bazaar.GetIndex(&ctx)
+	if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	data, err := json.Marshal(index.Products())
+	if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		ctx.Logger.Error("Failed to encode bazaar", "err", err)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	_, _ = res.Write(data)
+}
+
+// GetBazaarHistory returns the hourly prices of a product by product id or sb id, query parameters are the same as
+// for GetAuctionHistory
+func GetBazaarHistory(ctx internal.RouteContext, res http.ResponseWriter, req *http.Request) {
+	id := req.PathValue("id")
+	query := req.URL.Query()
+
+	interval := query.Get("interval")
+	if interval == "" {
+		interval = "hourly"
+	}
+	if _, ok := bazaar.HistoryIntervals[interval]; !ok {
+		res.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	from, to, err := parseHistoryWindow(query.Get("from"), query.Get("to"), query.Get("window"))
+	if err != nil || !from.Before(to) || to.Sub(from) > maxHistoryWindow {
+		res.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	points, err := bazaar.GetHistory(&ctx, id, from, to, interval)
+	if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		ctx.Logger.Error("Failed to fetch price history", "id", id, "err", err)
+		return
+	}
+
+	data, err := json.Marshal(points)
+	if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		ctx.Logger.Error("Failed to encode price history", "id", id, "err", err)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", int(auctionHistoryCacheDuration.Seconds())))
+	_, _ = res.Write(data)
+}
@@ -1,60 +1,22 @@
 package routes
 
 import (
-	"fmt"
-	"io"
-	"net/http"
-	"skyblock-pv-backend/internal"
 	"time"
 )
 
 const gardenCacheDuration = 10 * time.Minute
 const gardenFailedCacheDuration = 5 * time.Minute
+const gardenStaleCacheDuration = 24 * time.Hour
 const gardenCacheName = "garden"
-const gardenHypixelPath = "/v2/skyblock/garden"
+const gardenHypixelPath = "/v2/skyblock/garden?profile=%s"
 const failedGardenResponse = `{"success": false,"garden": {}}`
 
-func GetGarden(ctx internal.RouteContext, authentication internal.AuthenticationContext, res http.ResponseWriter, req *http.Request) {
-	profileId := req.PathValue("profile")
-	result, err := ctx.GetFromCache(&authentication, gardenCacheName, profileId)
-
-	if err != nil {
-		if ctx.HasErrorCached(gardenCacheName, profileId) {
-			res.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-		profiles, err := internal.GetFromHypixel(ctx, fmt.Sprintf("%s?profile=%s", gardenHypixelPath, profileId), true)
-		if err == nil && profiles == nil {
-			err = ctx.AddToCache(gardenCacheName, profileId, failedGardenResponse, gardenCacheDuration)
-
-			res.Header().Set("Content-Type", "application/json")
-			res.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", int(gardenCacheDuration.Seconds())))
-			_, _ = io.WriteString(res, failedGardenResponse)
-			return
-		} else if err == nil {
-			err = ctx.AddToCache(gardenCacheName, profileId, profiles, gardenCacheDuration)
-		} else {
-			cacheError := ctx.AddToErrorCache(gardenCacheName, profileId, gardenFailedCacheDuration)
-			if cacheError != nil {
-				fmt.Printf("Failed to cache garden error: %v\n", cacheError)
-			}
-		}
-
-		if err != nil {
-			res.WriteHeader(http.StatusInternalServerError)
-			fmt.Printf(
-				"[/garden/%s] User '%s' with user-agent '%s' failed to fetch or cache garden: %v\n",
-				profileId,
-				authentication.Requester,
-				req.Header.Get("User-Agent"),
-				err,
-			)
-			return
-		}
-		result = *profiles
-	}
-
-	res.Header().Set("Content-Type", "application/json")
-	res.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", int(gardenCacheDuration.Seconds())))
-	_, _ = io.WriteString(res, result)
-}
+var GetGarden = ProxyRoute{
+	CacheName:           gardenCacheName,
+	HypixelPath:         gardenHypixelPath,
+	PathValue:           "profile",
+	CacheDuration:       gardenCacheDuration,
+	FailedCacheDuration: gardenFailedCacheDuration,
+	StaleDuration:       gardenStaleCacheDuration,
+	NotFoundBody:        failedGardenResponse,
+}.Handle
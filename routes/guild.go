@@ -3,8 +3,6 @@ package routes
 import (
 	"encoding/json"
 	"fmt"
-	"io"
-	"net/http"
 	"skyblock-pv-backend/internal"
 	"skyblock-pv-backend/utils/responses"
 	"strings"
@@ -12,10 +10,13 @@ import (
 )
 
 const guildCacheDuration = 5 * 24 * time.Hour
+const guildStaleCacheDuration = 14 * 24 * time.Hour
 const guildCacheName = "guild"
-const guildHypixelPath = "/v2/guild"
+const guildHypixelPath = "/v2/guild?player=%s"
 
-func cacheGuild(ctx internal.RouteContext, guild string) error {
+// cacheGuild caches the guild for every member, it is also stored under the requested key so replicas waiting
+// for the fetch find it
+func cacheGuild(ctx internal.RouteContext, key string, guild string, duration time.Duration) error {
 	var response = responses.GuildResponse{}
 	err := json.Unmarshal([]byte(guild), &response)
 	if err != nil {
@@ -26,13 +27,21 @@ func cacheGuild(ctx internal.RouteContext, guild string) error {
 		return fmt.Errorf("failed to fetch guild: %s", response.Guild.Name)
 	}
 
+	err = ctx.AddToCache(guildCacheName, key, guild, duration)
+	if err != nil {
+		return err
+	}
+
 	for _, member := range response.Guild.Members {
 		realUuid := strings.Join(
 			[]string{member.Uuid[0:8], member.Uuid[8:12], member.Uuid[12:16], member.Uuid[16:20], member.Uuid[20:]},
 			"-",
 		)
+		if realUuid == key {
+			continue
+		}
 
-		err = ctx.AddToCache(guildCacheName, realUuid, guild, guildCacheDuration)
+		err = ctx.AddToCache(guildCacheName, realUuid, guild, duration)
 		if err != nil {
 			return err
 		}
@@ -41,25 +50,11 @@ func cacheGuild(ctx internal.RouteContext, guild string) error {
 	return nil
 }
 
-func GetGuild(ctx internal.RouteContext, authentication internal.AuthenticationContext, res http.ResponseWriter, req *http.Request) {
-	playerId := req.PathValue("id")
-	result, err := ctx.GetFromCache(&authentication, guildCacheName, playerId)
-
-	if err != nil {
-		guild, err := internal.GetFromHypixel(ctx, fmt.Sprintf("%s?player=%s", guildHypixelPath, playerId), true)
-		if err == nil && guild != nil {
-			err = cacheGuild(ctx, *guild)
-		}
-
-		if err != nil || guild == nil {
-			res.WriteHeader(http.StatusInternalServerError)
-			fmt.Printf("Failed to fetch or cache guild: %v\n", err)
-			return
-		}
-		result = *guild
-	}
-
-	res.Header().Set("Content-Type", "application/json")
-	res.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", int(guildCacheDuration.Seconds())))
-	_, _ = io.WriteString(res, result)
-}
+var GetGuild = ProxyRoute{
+	CacheName:     guildCacheName,
+	HypixelPath:   guildHypixelPath,
+	PathValue:     "id",
+	CacheDuration: guildCacheDuration,
+	StaleDuration: guildStaleCacheDuration,
+	Store:         cacheGuild,
+}.Handle
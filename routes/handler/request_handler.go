@@ -31,7 +31,7 @@ func (handler PrivateRequestHandler) Handle(ctx internal.RouteContext, res http.
 	if context == nil {
 		res.WriteHeader(http.StatusUnauthorized)
 	} else {
-		handler.Handler(ctx, *context, res, req)
+		handler.Handler(ctx.WithRequester(context.Requester), *context, res, req)
 	}
 }
 
@@ -46,7 +46,7 @@ func (handler AuthenticatedRequestHandler) Handle(ctx internal.RouteContext, res
 	if context == nil || context.IsGuest {
 		res.WriteHeader(http.StatusUnauthorized)
 	} else {
-		handler.Handler(ctx, *context, res, req)
+		handler.Handler(ctx.WithRequester(context.Requester), *context, res, req)
 	}
 }
 
@@ -61,7 +61,7 @@ func (handler AdminRequestHandler) Handle(ctx internal.RouteContext, res http.Re
 	if context == nil || context.IsGuest || !slices.Contains(ctx.Config.Admins, context.Requester) {
 		res.WriteHeader(http.StatusNotFound)
 	} else {
-		handler.Handler(ctx, *context, res, req)
+		handler.Handler(ctx.WithRequester(context.Requester), *context, res, req)
 	}
 }
 
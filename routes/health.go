@@ -0,0 +1,177 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"runtime"
+	"skyblock-pv-backend/auctions"
+	"skyblock-pv-backend/bazaar"
+	"skyblock-pv-backend/internal"
+	"time"
+)
+
+const readinessTimeout = 2 * time.Second
+
+const (
+	checkOk = "ok"
+	// degraded checks are reported but don't make the instance unready
+	checkDegraded = "degraded"
+	checkFailed   = "failed"
+	checkDisabled = "disabled"
+)
+
+var startedAt = time.Now()
+
+type readinessCheck struct {
+	Status  string         `json:"status"`
+	Error   string         `json:"error,omitempty"`
+	Details map[string]any `json:"details,omitempty"`
+}
+
+type readinessResponse struct {
+	Ready  bool                      `json:"ready"`
+	Checks map[string]readinessCheck `json:"checks"`
+}
+
+func writeHealth(res http.ResponseWriter, status int, body any) {
+	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("Cache-Control", "no-store")
+	res.WriteHeader(status)
+	_ = json.NewEncoder(res).Encode(body)
+}
+
+// GetHealth only reports that the process is able to answer
+func GetHealth(_ internal.RouteContext, res http.ResponseWriter, _ *http.Request) {
+	writeHealth(res, http.StatusOK, map[string]any{
+		"status":         checkOk,
+		"uptime_seconds": int64(time.Since(startedAt).Seconds()),
+	})
+}
+
+// GetReadiness checks the dependencies of the instance, it responds with 503 if any check failed
+func GetReadiness(ctx internal.RouteContext, res http.ResponseWriter, req *http.Request) {
+	timeout, cancel := context.WithTimeout(req.Context(), readinessTimeout)
+	defer cancel()
+
+	response := readinessResponse{Ready: true, Checks: map[string]readinessCheck{
+		"redis":        checkRedis(ctx, timeout),
+		"postgres":     checkPostgres(ctx, timeout),
+		"migrations":   checkMigrations(ctx, timeout),
+		"auctions":     checkAuctions(ctx),
+		"hypixel_keys": checkKeys(ctx),
+	}}
+	for _, check := range response.Checks {
+		if check.Status == checkFailed {
+			response.Ready = false
+		}
+	}
+
+	status := http.StatusOK
+	if !response.Ready {
+		status = http.StatusServiceUnavailable
+	}
+	writeHealth(res, status, response)
+}
+
+func failed(err error, details map[string]any) readinessCheck {
+	return readinessCheck{Status: checkFailed, Error: err.Error(), Details: details}
+}
+
+func latency(start time.Time) map[string]any {
+	return map[string]any{"latency_ms": float64(time.Since(start).Microseconds()) / 1000}
+}
+
+func checkRedis(ctx internal.RouteContext, timeout context.Context) readinessCheck {
+	start := time.Now()
+	err := ctx.PingRedis(timeout)
+	if errors.Is(err, internal.ErrRedisDisabled) {
+		return readinessCheck{Status: checkDisabled}
+	} else if err != nil {
+		return failed(err, latency(start))
+	}
+	return readinessCheck{Status: checkOk, Details: latency(start)}
+}
+
+func checkPostgres(ctx internal.RouteContext, timeout context.Context) readinessCheck {
+	start := time.Now()
+	if err := ctx.Pool.Ping(timeout); err != nil {
+		return failed(err, latency(start))
+	}
+	stat := ctx.Pool.Stat()
+	details := latency(start)
+	details["connections"] = stat.TotalConns()
+	details["max_connections"] = stat.MaxConns()
+	return readinessCheck{Status: checkOk, Details: details}
+}
+
+// checkMigrations fails if the database isn't at the newest migration of this build or a migration failed halfway
+func checkMigrations(ctx internal.RouteContext, timeout context.Context) readinessCheck {
+	expected, err := internal.LatestMigration()
+	if err != nil {
+		return failed(err, nil)
+	}
+	version, dirty, err := ctx.MigrationVersion(timeout)
+	if err != nil {
+		return failed(err, map[string]any{"expected": expected})
+	}
+
+	details := map[string]any{"version": version, "expected": expected, "dirty": dirty}
+	if dirty {
+		return failed(errors.New("the last migration did not finish"), details)
+	} else if version != expected {
+		return failed(errors.New("the database is not at the expected version"), details)
+	}
+	return readinessCheck{Status: checkOk, Details: details}
+}
+
+func checkAuctions(ctx internal.RouteContext) readinessCheck {
+	maxAge := time.Duration(ctx.Config.Health.MaxAuctionAgeMilli) * time.Millisecond
+	lastUpdated, ok := auctions.LastUpdated()
+	if !ok {
+		return failed(errors.New("no auctions have been loaded yet"), map[string]any{"max_age_seconds": int64(maxAge.Seconds())})
+	}
+
+	age := time.Since(lastUpdated)
+	details := map[string]any{
+		"last_updated":    lastUpdated.UnixMilli(),
+		"age_seconds":     int64(age.Seconds()),
+		"max_age_seconds": int64(maxAge.Seconds()),
+	}
+	if age > maxAge {
+		return failed(errors.New("the auctions are older than the maximum age"), details)
+	}
+	return readinessCheck{Status: checkOk, Details: details}
+}
+
+// checkKeys is degraded if no api key is usable, every instance shares the keys so it doesn't fail readiness
+func checkKeys(ctx internal.RouteContext) readinessCheck {
+	if ctx.Keys.Size() == 0 {
+		return readinessCheck{Status: checkDisabled}
+	}
+	available := 0
+	for _, key := range ctx.Keys.States() {
+		if key.Available {
+			available++
+		}
+	}
+
+	details := map[string]any{"available": available, "total": ctx.Keys.Size()}
+	if available == 0 {
+		details["available_in_seconds"] = int64(time.Until(ctx.Keys.NextAvailable()).Seconds())
+		return readinessCheck{Status: checkDegraded, Error: internal.ErrNoKeyAvailable.Error(), Details: details}
+	}
+	return readinessCheck{Status: checkOk, Details: details}
+}
+
+// GetVersion returns the build of the instance and the cache versions it reads and writes
+func GetVersion(_ internal.RouteContext, res http.ResponseWriter, _ *http.Request) {
+	writeHealth(res, http.StatusOK, map[string]any{
+		"version":               internal.Version,
+		"commit":                internal.BuildCommit(),
+		"go_version":            runtime.Version(),
+		"auction_cache_version": auctions.AuthCacheVersion,
+		"bazaar_cache_version":  bazaar.CacheVersion,
+	})
+}
@@ -0,0 +1,78 @@
+package routes
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"skyblock-pv-backend/internal"
+	"skyblock-pv-backend/utils"
+	"skyblock-pv-backend/utils/text"
+	"strings"
+)
+
+const maxLoreBodySize = 1 << 20
+
+// LoreRequest contains either an item (base64 encoded nbt, e.g. item_bytes of an auction) or the name and lore
+// of an auction, lines of the lore are separated by \n
+type LoreRequest struct {
+	Item string `json:"item,omitempty"`
+	Name string `json:"name,omitempty"`
+	Lore string `json:"lore,omitempty"`
+}
+
+type LoreResponse struct {
+	Name      []text.Span    `json:"name"`
+	PlainName string         `json:"plain_name"`
+	Lore      [][]text.Span  `json:"lore"`
+	Facts     text.LoreFacts `json:"facts"`
+}
+
+// PostLore splits the formatting codes of an item name and lore into spans and reads the rarity line of the lore
+func PostLore(_ internal.RouteContext, _ internal.AuthenticationContext, res http.ResponseWriter, req *http.Request) {
+	//goland:noinspection GoUnhandledErrorResult
+	defer req.Body.Close()
+	data, err := io.ReadAll(http.MaxBytesReader(res, req.Body, maxLoreBodySize))
+	if err != nil {
+		res.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	var request LoreRequest
+	if err := json.Unmarshal(data, &request); err != nil || (request.Item == "") == (request.Name == "" && request.Lore == "") {
+		res.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	name := request.Name
+	lines := make([]string, 0)
+	if request.Lore != "" {
+		lines = strings.Split(request.Lore, "\n")
+	}
+	if request.Item != "" {
+		items, err := utils.DecodeInventory(request.Item)
+		if err != nil || len(items) != 1 {
+			res.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		name = items[0].DisplayName()
+		lines = items[0].Lore()
+	}
+
+	response := LoreResponse{
+		Name:  text.Parse(name),
+		Lore:  make([][]text.Span, len(lines)),
+		Facts: text.ParseLore(lines),
+	}
+	response.PlainName = text.Plain(response.Name)
+	for i, line := range lines {
+		response.Lore[i] = text.Parse(line)
+	}
+
+	converted, err := json.Marshal(response)
+	if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	res.Header().Set("Content-Type", "application/json")
+	_, _ = res.Write(converted)
+}
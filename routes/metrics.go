@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"net/http"
+	"skyblock-pv-backend/internal"
+	"skyblock-pv-backend/internal/metrics"
+)
+
+// GetMetrics writes the metrics in the Prometheus text format, it is only served if endpoints.metrics is enabled
+func GetMetrics(ctx internal.RouteContext, res http.ResponseWriter, _ *http.Request) {
+	if !ctx.Config.Endpoints.Metrics {
+		res.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	res.Header().Set("Content-Type", metrics.ContentType)
+	if err := metrics.Default.Write(res); err != nil {
+		ctx.Logger.Error("Failed to write metrics", "err", err)
+	}
+}
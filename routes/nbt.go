@@ -0,0 +1,111 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"skyblock-pv-backend/internal"
+	"skyblock-pv-backend/utils/nbt"
+)
+
+const maxNbtBodySize = 1 << 20
+
+type NbtRequest struct {
+	// base64 encoded nbt, optionally gzip compressed
+	Data string `json:"data"`
+}
+
+// PostNbt converts base64 encoded nbt to json, query parameter format is plain (default) or typed
+func PostNbt(_ internal.RouteContext, _ internal.AuthenticationContext, res http.ResponseWriter, req *http.Request) {
+	//goland:noinspection GoUnhandledErrorResult
+	defer req.Body.Close()
+	typed, ok := parseNbtFormat(req.URL.Query().Get("format"))
+	if !ok {
+		res.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(res, req.Body, maxNbtBodySize))
+	if err != nil {
+		res.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	var request NbtRequest
+	if err := json.Unmarshal(data, &request); err != nil || request.Data == "" {
+		res.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	converted, err := nbtToJson(request.Data, typed)
+	if err != nil {
+		res.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	_, _ = res.Write(converted)
+}
+
+func parseNbtFormat(format string) (bool, bool) {
+	switch format {
+	case "", "plain":
+		return false, true
+	case "typed":
+		return true, true
+	}
+	return false, false
+}
+
+func nbtToJson(data string, typed bool) ([]byte, error) {
+	decoded, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, err
+	}
+	tag, err := nbt.Decode(bytes.NewReader(decoded))
+	if err != nil {
+		return nil, err
+	}
+	if typed {
+		return tag.TypedJSON(), nil
+	}
+	return tag.PlainJSON(), nil
+}
+
+// expandInventories replaces the data of every inventory ({"type":0,"data":"..."}) in the json with the decoded nbt
+func expandInventories(body string, typed bool) (string, error) {
+	var root interface{}
+	if err := json.Unmarshal([]byte(body), &root); err != nil {
+		return "", err
+	}
+	root = expandValue(root, typed)
+	data, err := json.Marshal(root)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func expandValue(value interface{}, typed bool) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		data, isString := value["data"].(string)
+		if _, hasType := value["type"].(float64); hasType && isString {
+			// blobs that can't be decoded are kept as they are
+			if converted, err := nbtToJson(data, typed); err == nil {
+				value["data"] = json.RawMessage(converted)
+			}
+			return value
+		}
+		for key, child := range value {
+			value[key] = expandValue(child, typed)
+		}
+	case []interface{}:
+		for i, child := range value {
+			value[i] = expandValue(child, typed)
+		}
+	}
+	return value
+}
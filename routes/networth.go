@@ -0,0 +1,101 @@
+package routes
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"skyblock-pv-backend/auctions"
+	"skyblock-pv-backend/bazaar"
+	"skyblock-pv-backend/internal"
+	"skyblock-pv-backend/networth"
+	"strings"
+	"time"
+)
+
+const networthCacheName = "networth"
+
+// getPriceSource prefers bazaar prices over auction prices, if one of them isn't available only the other is used
+func getPriceSource(ctx internal.RouteContext) (networth.PriceSource, error) {
+	auctionIndex, auctionErr := auctions.GetPriceIndex(&ctx)
+	bazaarIndex, bazaarErr := bazaar.GetIndex(&ctx)
+	switch {
+	case auctionErr != nil && bazaarErr != nil:
+		ctx.Logger.Error("Failed to load prices", "auction_err", auctionErr, "bazaar_err", bazaarErr)
+		return nil, errors.Join(auctionErr, bazaarErr)
+	case auctionErr != nil:
+		return bazaarIndex, nil
+	case bazaarErr != nil:
+		return auctionIndex, nil
+	}
+	return networth.PriceSources{bazaarIndex, auctionIndex}, nil
+}
+
+// GetNetworth prices every member of a profile, the value is cached until the cached profiles expire
+func GetNetworth(ctx internal.RouteContext, authentication internal.AuthenticationContext, res http.ResponseWriter, req *http.Request) {
+	player := req.PathValue("player")
+	profileId := strings.ToLower(strings.ReplaceAll(req.PathValue("profile"), "-", ""))
+	key := player + ":" + profileId
+
+	if cached, err := ctx.GetFromCache(&authentication, networthCacheName, key); err == nil {
+		ctx.SetCacheStatus(internal.CacheHit)
+		ttl, err := ctx.GetTtlMilli(networthCacheName, key)
+		if err != nil {
+			ttl = -1
+		}
+		profilesRoute.write(res, cached, ttl*time.Millisecond)
+		return
+	}
+
+	profiles, err := profilesRoute.Get(ctx, &authentication, player)
+	if profilesRoute.writeFailure(ctx, res, profiles, err) {
+		return
+	}
+
+	prices, err := getPriceSource(ctx)
+	if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	value, err := networth.ValueProfile(prices, profiles.Body, profileId)
+	if errors.Is(err, networth.ErrProfileNotFound) {
+		res.WriteHeader(http.StatusNotFound)
+		return
+	} else if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		ctx.Logger.Error("Failed to calculate networth", "err", err)
+		return
+	}
+
+	for _, failure := range value.Failures {
+		// inventories can be hundreds of kilobytes, the hash is enough to find the same blob again
+		hash := sha256.Sum256([]byte(failure.Data))
+		ctx.Logger.Error("Failed to decode inventory",
+			"member", failure.Member,
+			"category", failure.Category,
+			"data_length", len(failure.Data),
+			"data_sha256", hex.EncodeToString(hash[:8]),
+			"err", failure.Err,
+		)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		ctx.Logger.Error("Failed to encode networth", "err", err)
+		return
+	}
+
+	if profiles.Stale {
+		profilesRoute.writeStale(res, &internal.FetchResult{Body: string(data), Age: profiles.Age})
+		return
+	}
+	if profiles.Ttl > 0 {
+		if err := ctx.AddToCache(networthCacheName, key, data, profiles.Ttl); err != nil {
+			ctx.Logger.Error("Failed to cache networth", "err", err)
+		}
+	}
+	profilesRoute.write(res, string(data), profiles.Ttl)
+}
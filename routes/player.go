@@ -1,58 +1,25 @@
 package routes
 
 import (
-	"fmt"
-	"io"
-	"net/http"
 	"skyblock-pv-backend/internal"
 	"time"
 )
 
 const playerCacheDuration = 12 * time.Hour
 const playerFailedCacheDuration = 5 * time.Minute
+const playerStaleCacheDuration = 3 * 24 * time.Hour
 const playerCacheName = "player"
-const playerHypixelPath = "/v2/player"
+const playerHypixelPath = "/v2/player?uuid=%s"
 
-func GetPlayer(ctx internal.RouteContext, authentication internal.AuthenticationContext, res http.ResponseWriter, req *http.Request) {
-	if !ctx.Config.Endpoints.Players {
-		res.Header().Set("Content-Type", "application/json")
-		res.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", int(playerCacheDuration.Seconds())))
-		_, _ = io.WriteString(res, "{}")
-	} else {
-		playerId := req.PathValue("id")
-		result, err := ctx.GetFromCache(&authentication, playerCacheName, playerId)
-
-		if err != nil {
-			if ctx.HasErrorCached(playerCacheName, playerId) {
-				res.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-			profiles, err := internal.GetFromHypixel(ctx, fmt.Sprintf("%s?uuid=%s", playerHypixelPath, playerId), true)
-			if err == nil && profiles != nil {
-				err = ctx.AddToCache(playerCacheName, playerId, profiles, playerCacheDuration)
-			} else {
-				cacheError := ctx.AddToErrorCache(playerCacheName, playerId, playerFailedCacheDuration)
-				if cacheError != nil {
-					fmt.Printf("Failed to cache player error: %v\n", cacheError)
-				}
-			}
-
-			if err != nil || profiles == nil {
-				res.WriteHeader(http.StatusInternalServerError)
-				fmt.Printf(
-					"[/player/%s] User '%s' with user-agent '%s' failed to fetch or cache player: %v\n",
-					playerId,
-					authentication.Requester,
-					req.Header.Get("User-Agent"),
-					err,
-				)
-				return
-			}
-			result = *profiles
-		}
-
-		res.Header().Set("Content-Type", "application/json")
-		res.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", int(playerCacheDuration.Seconds())))
-		_, _ = io.WriteString(res, result)
-	}
-}
+var GetPlayer = ProxyRoute{
+	CacheName:           playerCacheName,
+	HypixelPath:         playerHypixelPath,
+	PathValue:           "id",
+	CacheDuration:       playerCacheDuration,
+	FailedCacheDuration: playerFailedCacheDuration,
+	StaleDuration:       playerStaleCacheDuration,
+	Enabled: func(ctx internal.RouteContext) bool {
+		return ctx.Config.Endpoints.Players
+	},
+	DisabledBody: "{}",
+}.Handle
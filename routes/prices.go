@@ -0,0 +1,87 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"skyblock-pv-backend/auctions"
+	"skyblock-pv-backend/bazaar"
+	"skyblock-pv-backend/internal"
+	"slices"
+	"strings"
+)
+
+const (
+	PriceSourceBazaar  = "bazaar"
+	PriceSourceAuction = "auction"
+)
+
+// PriceEntry is the price of an item, Source tells where Price comes from. Items that are sold on the bazaar and
+// the auction house use the bazaar price if it has a buy price, the auction prices are still included.
+type PriceEntry struct {
+	Source string `json:"source"`
+	// Price is the instant buy price of bazaar products and the lowest bin of auctions
+	Price   float64            `json:"price"`
+	Bazaar  *BazaarPrice       `json:"bazaar,omitempty"`
+	Auction *auctions.ItemInfo `json:"auction,omitempty"`
+}
+
+type BazaarPrice struct {
+	BuyPrice       float64 `json:"buy_price"`
+	SellPrice      float64 `json:"sell_price"`
+	BuyMovingWeek  int64   `json:"buy_moving_week"`
+	SellMovingWeek int64   `json:"sell_moving_week"`
+}
+
+// GetPrices merges the bazaar and auction prices by sb id, query parameter ids (comma separated) selects items.
+// Prices of one source are still returned if the other one isn't available.
+func GetPrices(ctx internal.RouteContext, res http.ResponseWriter, req *http.Request) {
+	auctionIndex, auctionErr := auctions.GetPriceIndex(&ctx)
+	bazaarIndex, bazaarErr := bazaar.GetIndex(&ctx)
+	if auctionErr != nil && bazaarErr != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		ctx.Logger.Error("Failed to load prices", "auction_err", auctionErr, "bazaar_err", bazaarErr)
+		return
+	}
+
+	var ids []string
+	if query := req.URL.Query(); query.Has("ids") {
+		ids = strings.Split(query.Get("ids"), ",")
+	}
+
+	prices := make(map[string]PriceEntry)
+	if auctionErr == nil {
+		items := auctionIndex.WithPrefix("")
+		if ids != nil {
+			items = auctionIndex.Select(ids)
+		}
+		for id, info := range items {
+			prices[id] = PriceEntry{Source: PriceSourceAuction, Price: float64(info.Lowest), Auction: &info}
+		}
+	}
+	if bazaarErr == nil {
+		for id, product := range bazaarIndex.Products() {
+			if ids != nil && !slices.Contains(ids, id) {
+				continue
+			}
+			entry := prices[id]
+			// products without sell offers have no buy price, the auction price is used if there is one
+			if product.BuyPrice > 0 || entry.Auction == nil {
+				entry.Source = PriceSourceBazaar
+				entry.Price = product.BuyPrice
+			}
+			entry.Bazaar = &BazaarPrice{product.BuyPrice, product.SellPrice, product.BuyMovingWeek, product.SellMovingWeek}
+			prices[id] = entry
+		}
+	}
+
+	data, err := json.Marshal(prices)
+	if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		ctx.Logger.Error("Failed to encode prices", "err", err)
+		return
+	}
+	res.Header().Set("X-Auction-Version", fmt.Sprintf("v%d", auctions.AuthCacheVersion))
+	res.Header().Set("Content-Type", "application/json")
+	_, _ = res.Write(data)
+}
@@ -2,44 +2,22 @@ package routes
 
 import (
 	"encoding/json"
-	"fmt"
-	"io"
-	"net/http"
-	"skyblock-pv-backend/internal"
 	"time"
 )
 
 const playerAuctionsCacheDuration = 10 * time.Minute
+const playerAuctionsStaleCacheDuration = time.Hour
 const playerAuctionsCacheName = "player_active_auctions"
-const playerAuctionsHypixelPath = "/v2/skyblock/auction"
-
-func GetActiveProfileAuctions(ctx internal.RouteContext, authentication internal.AuthenticationContext, res http.ResponseWriter, req *http.Request) {
-	profileId := req.PathValue("profile")
-	result, err := ctx.GetFromCache(&authentication, playerAuctionsCacheName, profileId)
-
-	if err != nil {
-		auctions, err := internal.GetFromHypixel(ctx, fmt.Sprintf("%s?profile=%s", playerAuctionsHypixelPath, profileId), true)
-		if err == nil && auctions != nil {
-			transformedAuctions, err := transformAuctions(*auctions)
-			auctions = &transformedAuctions
-
-			if err == nil {
-				err = ctx.AddToCache(playerAuctionsCacheName, profileId, auctions, playerAuctionsCacheDuration)
-			}
-		}
-
-		if err != nil || auctions == nil {
-			res.WriteHeader(http.StatusInternalServerError)
-			fmt.Printf("Failed to fetch or cache player active auctions: %v\n", err)
-			return
-		}
-		result = *auctions
-	}
-
-	res.Header().Set("Content-Type", "application/json")
-	res.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", int(playerAuctionsCacheDuration.Seconds())))
-	_, _ = io.WriteString(res, result)
-}
+const playerAuctionsHypixelPath = "/v2/skyblock/auction?profile=%s"
+
+var GetActiveProfileAuctions = ProxyRoute{
+	CacheName:     playerAuctionsCacheName,
+	HypixelPath:   playerAuctionsHypixelPath,
+	PathValue:     "profile",
+	CacheDuration: playerAuctionsCacheDuration,
+	StaleDuration: playerAuctionsStaleCacheDuration,
+	Transform:     transformAuctions,
+}.Handle
 
 func transformAuctions(auctionsText string) (string, error) {
 	var auctions = make(map[string]interface{})
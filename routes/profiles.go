@@ -2,19 +2,17 @@ package routes
 
 import (
 	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
 	"skyblock-pv-backend/internal"
-	"strconv"
 	"time"
 )
 
 const profileCacheDuration = 5 * time.Minute
 const highProfileCacheDuration = 15 * time.Minute
 const profileFailedCacheDuration = 3 * time.Minute
+const profileStaleCacheDuration = 24 * time.Hour
 const profileCacheName = "profiles"
-const profileHypixelPath = "/v2/skyblock/profiles"
+const profileHypixelPath = "/v2/skyblock/profiles?uuid=%s"
 
 type profileResponse struct {
 	Status   bool      `json:"success"`
@@ -25,71 +23,59 @@ type profile struct {
 	ProfileId string `json:"profile_id"`
 }
 
-func GetProfiles(ctx internal.RouteContext, authentication internal.AuthenticationContext, res http.ResponseWriter, req *http.Request) {
-	playerId := req.PathValue("id")
-	result, err := ctx.GetFromCache(&authentication, profileCacheName, playerId)
-
-	if err != nil {
-		if ctx.HasErrorCached(profileCacheName, playerId) {
-			res.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-		profiles, err := internal.GetFromHypixel(ctx, fmt.Sprintf("%s?uuid=%s", profileHypixelPath, playerId), true)
-		if err == nil && profiles != nil {
-			cacheDuration := profileCacheDuration
-			if ctx.IsHighProfileAccount(playerId) {
-				cacheDuration = highProfileCacheDuration
-			}
-			err = ctx.AddToCache(profileCacheName, playerId, profiles, cacheDuration)
-		} else {
-			cacheError := ctx.AddToErrorCache(profileCacheName, playerId, profileFailedCacheDuration)
-			if cacheError != nil {
-				fmt.Printf("Failed to cache profiles error: %v\n", cacheError)
-			}
-		}
-
-		go func() {
-			data := profiles
-			if data == nil {
-				return
-			}
-
-			response := profileResponse{}
-			if err := json.Unmarshal([]byte(*data), &response); err != nil || response.Profiles == nil {
-				return
-			}
-
-			profileIds := make([]string, len(response.Profiles))
-			for i, p := range response.Profiles {
-				profileIds[i] = p.ProfileId
-			}
+var profilesRoute = ProxyRoute{
+	CacheName:                profileCacheName,
+	HypixelPath:              profileHypixelPath,
+	PathValue:                "id",
+	CacheDuration:            profileCacheDuration,
+	HighProfileCacheDuration: highProfileCacheDuration,
+	FailedCacheDuration:      profileFailedCacheDuration,
+	StaleDuration:            profileStaleCacheDuration,
+	ExposeExpiry:             true,
+	OnFetched:                checkProfiles,
+}
 
-			CheckData(ctx, playerId, profileIds)
-		}()
+// GetProfiles returns the profiles of a player, inventories are decoded inline with the query parameter
+// expand set to plain or typed
+func GetProfiles(ctx internal.RouteContext, authentication internal.AuthenticationContext, res http.ResponseWriter, req *http.Request) {
+	if !req.URL.Query().Has("expand") {
+		profilesRoute.Handle(ctx, authentication, res, req)
+		return
+	}
+	typed, ok := parseNbtFormat(req.URL.Query().Get("expand"))
+	if !ok {
+		res.WriteHeader(http.StatusBadRequest)
+		return
+	}
 
-		if err != nil || profiles == nil {
-			res.WriteHeader(http.StatusInternalServerError)
-			fmt.Printf(
-				"[/profiles/%s] User '%s' with user-agent '%s' failed to fetch or cache profiles: %v\n",
-				playerId,
-				authentication.Requester,
-				req.Header.Get("User-Agent"),
-				err,
-			)
-			return
-		}
-		result = *profiles
+	result, err := profilesRoute.Get(ctx, &authentication, req.PathValue(profilesRoute.PathValue))
+	if profilesRoute.writeFailure(ctx, res, result, err) {
+		return
 	}
 
-	milli, err := ctx.GetTtlMilli(profileCacheName, playerId)
+	expanded, err := expandInventories(result.Body, typed)
 	if err != nil {
 		res.WriteHeader(http.StatusInternalServerError)
-		fmt.Printf("Failed to fetch or cache profiles: %v\n", err)
+		ctx.Logger.Error("Failed to expand inventories", "err", err)
+		return
+	}
+	if result.Stale {
+		profilesRoute.writeStale(res, &internal.FetchResult{Body: expanded, Age: result.Age})
+		return
+	}
+	profilesRoute.write(res, expanded, result.Ttl)
+}
+
+func checkProfiles(ctx internal.RouteContext, playerId string, profiles string) {
+	response := profileResponse{}
+	if err := json.Unmarshal([]byte(profiles), &response); err != nil || response.Profiles == nil {
 		return
 	}
 
-	res.Header().Set("Content-Type", "application/json")
-	res.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", int(profileCacheDuration.Seconds())))
-	res.Header().Set("X-Backend-Expire-In", strconv.FormatInt(int64(milli), 10))
-	_, _ = io.WriteString(res, result)
+	profileIds := make([]string, len(response.Profiles))
+	for i, p := range response.Profiles {
+		profileIds[i] = p.ProfileId
+	}
+
+	CheckData(ctx, playerId, profileIds)
 }
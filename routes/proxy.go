@@ -0,0 +1,238 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"skyblock-pv-backend/internal"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const staleMaxAge = time.Minute
+
+// entries with a scheduled background refresh
+var revalidating sync.Map
+
+// ProxyRoute serves a hypixel endpoint through the cache, one instance describes a complete route
+type ProxyRoute struct {
+	// used as cache prefix and in log messages
+	CacheName string
+	// path on the hypixel api including the query, the path value replaces the %s
+	HypixelPath string
+	// name of the path value used as cache key
+	PathValue                string
+	CacheDuration            time.Duration
+	HighProfileCacheDuration time.Duration
+	// how long failed fetches are remembered, zero disables the error cache
+	FailedCacheDuration time.Duration
+	// how long the last known good copy is kept to be served while hypixel fails, zero disables it
+	StaleDuration time.Duration
+	// cached and served when hypixel responds with 404, an empty body fails the request instead
+	NotFoundBody string
+	// sets X-Backend-Expire-In to the remaining cache duration in milliseconds
+	ExposeExpiry bool
+	// served when the route is disabled in the config
+	Enabled      func(ctx internal.RouteContext) bool
+	DisabledBody string
+	// applied to the hypixel response before it is cached
+	Transform func(body string) (string, error)
+	// replaces the default cache write, e.g. to cache the response under multiple keys
+	Store func(ctx internal.RouteContext, key string, body string, duration time.Duration) error
+	// runs in the background after a successful fetch
+	OnFetched func(ctx internal.RouteContext, key string, body string)
+}
+
+func (route ProxyRoute) Handle(ctx internal.RouteContext, authentication internal.AuthenticationContext, res http.ResponseWriter, req *http.Request) {
+	if route.Enabled != nil && !route.Enabled(ctx) {
+		route.write(res, route.DisabledBody, -1)
+		return
+	}
+
+	key := req.PathValue(route.PathValue)
+	result, err := route.Get(ctx, &authentication, key)
+	if route.writeFailure(ctx, res, result, err) {
+		return
+	}
+
+	if result.Stale {
+		route.writeStale(res, result)
+		return
+	}
+	route.write(res, result.Body, result.Ttl)
+}
+
+// writeFailure writes the status of a failed Get, returns false if there is a result to write
+func (route ProxyRoute) writeFailure(ctx internal.RouteContext, res http.ResponseWriter, result *internal.FetchResult, err error) bool {
+	if errors.Is(err, internal.ErrFailureCached) {
+		res.WriteHeader(http.StatusInternalServerError)
+		return true
+	} else if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		ctx.Logger.Error("Failed to fetch or cache", "cache", route.CacheName, "err", err)
+		return true
+	} else if result.Status != http.StatusOK {
+		res.WriteHeader(result.Status)
+		return true
+	}
+	return false
+}
+
+// Get returns the cached response or fetches it from hypixel, the ttl is only known on cache hits if ExposeExpiry is set
+func (route ProxyRoute) Get(ctx internal.RouteContext, authentication *internal.AuthenticationContext, key string) (*internal.FetchResult, error) {
+	result, err := ctx.GetFromCache(authentication, route.CacheName, key)
+	if err == nil {
+		ctx.SetCacheStatus(internal.CacheHit)
+		ttl := time.Duration(-1)
+		if route.ExposeExpiry {
+			milli, err := ctx.GetTtlMilli(route.CacheName, key)
+			if err != nil {
+				return nil, err
+			}
+			ttl = milli * time.Millisecond
+		}
+		return &internal.FetchResult{Body: result, Status: http.StatusOK, Ttl: ttl}, nil
+	}
+
+	if route.FailedCacheDuration > 0 && ctx.HasErrorCached(route.CacheName, key) {
+		if stale := route.getStale(ctx, key); stale != nil {
+			ctx.SetCacheStatus(internal.CacheStale)
+			return stale, nil
+		}
+		ctx.SetCacheStatus(internal.CacheHit)
+		return nil, internal.ErrFailureCached
+	}
+
+	ctx.SetCacheStatus(internal.CacheMiss)
+	fetched, err := ctx.Coalesce(route.CacheName, key, func() (*internal.FetchResult, error) {
+		return route.fetch(ctx, key)
+	})
+	cause := err
+	// replicas waiting for the lock holder get its failure as status instead of an error
+	if err == nil && fetched.Status != http.StatusOK {
+		cause = fmt.Errorf("fetch of another replica failed with status %d", fetched.Status)
+	}
+	if cause != nil && !errors.Is(cause, internal.ErrNotFound) {
+		if stale := route.getStale(ctx, key); stale != nil {
+			ctx.SetCacheStatus(internal.CacheStale)
+			go route.revalidate(ctx, key, cause)
+			return stale, nil
+		}
+	}
+	return fetched, err
+}
+
+func (route ProxyRoute) getStale(ctx internal.RouteContext, key string) *internal.FetchResult {
+	if route.StaleDuration <= 0 {
+		return nil
+	}
+	body, storedAt, err := ctx.GetFromStaleCache(route.CacheName, key)
+	if err != nil {
+		return nil
+	}
+	return &internal.FetchResult{Body: body, Status: http.StatusOK, Ttl: -1, Stale: true, Age: time.Since(storedAt)}
+}
+
+// revalidate refreshes a stale entry once hypixel or a key is expected to be available again
+func (route ProxyRoute) revalidate(ctx internal.RouteContext, key string, cause error) {
+	revalidateKey := route.CacheName + ":" + key
+	if _, running := revalidating.LoadOrStore(revalidateKey, true); running {
+		return
+	}
+	defer revalidating.Delete(revalidateKey)
+
+	delay := route.FailedCacheDuration
+	if errors.Is(cause, internal.ErrNoKeyAvailable) {
+		delay = time.Until(ctx.Keys.NextAvailable())
+	}
+	select {
+	case <-time.After(max(delay, staleMaxAge)):
+	case <-(*ctx.Context).Done():
+		return
+	}
+
+	if ctx.IsCached(route.CacheName, key) {
+		return
+	}
+	_, err := ctx.Coalesce(route.CacheName, key, func() (*internal.FetchResult, error) {
+		return route.fetch(ctx, key)
+	})
+	if err != nil {
+		ctx.Logger.Warn("Failed to revalidate", "cache", route.CacheName, "key", key, "err", err)
+	}
+}
+
+func (route ProxyRoute) fetch(ctx internal.RouteContext, key string) (*internal.FetchResult, error) {
+	cacheDuration := route.CacheDuration
+	if route.HighProfileCacheDuration > 0 && ctx.IsHighProfileAccount(key) {
+		cacheDuration = route.HighProfileCacheDuration
+	}
+
+	body, err := internal.GetFromHypixel(ctx, fmt.Sprintf(route.HypixelPath, key), true)
+	if err == nil && body == nil {
+		if route.NotFoundBody != "" {
+			// the response can still be served if caching it fails
+			_ = ctx.AddToCache(route.CacheName, key, route.NotFoundBody, cacheDuration)
+			return &internal.FetchResult{Body: route.NotFoundBody, Status: http.StatusOK, Ttl: cacheDuration}, nil
+		}
+		err = internal.ErrNotFound
+	}
+
+	if err != nil {
+		if route.FailedCacheDuration > 0 {
+			cacheError := ctx.AddToErrorCache(route.CacheName, key, err, route.FailedCacheDuration)
+			if cacheError != nil {
+				ctx.Logger.Error("Failed to cache error", "cache", route.CacheName, "err", cacheError)
+			}
+		}
+		return nil, err
+	}
+
+	if route.Transform != nil {
+		transformed, err := route.Transform(*body)
+		if err != nil {
+			return nil, err
+		}
+		body = &transformed
+	}
+
+	if route.Store != nil {
+		err = route.Store(ctx, key, *body, cacheDuration)
+	} else {
+		err = ctx.AddToCache(route.CacheName, key, *body, cacheDuration)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if route.StaleDuration > 0 {
+		if err := ctx.AddToStaleCache(route.CacheName, key, *body, route.StaleDuration); err != nil {
+			ctx.Logger.Error("Failed to cache stale copy", "cache", route.CacheName, "err", err)
+		}
+	}
+
+	if route.OnFetched != nil {
+		go route.OnFetched(ctx, key, *body)
+	}
+
+	return &internal.FetchResult{Body: *body, Status: http.StatusOK, Ttl: cacheDuration}, nil
+}
+
+func (route ProxyRoute) write(res http.ResponseWriter, body string, ttl time.Duration) {
+	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", int(route.CacheDuration.Seconds())))
+	if route.ExposeExpiry && ttl >= 0 {
+		res.Header().Set("X-Backend-Expire-In", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	_, _ = io.WriteString(res, body)
+}
+
+func (route ProxyRoute) writeStale(res http.ResponseWriter, result *internal.FetchResult) {
+	res.Header().Set("Content-Type", "application/json")
+	res.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", int(staleMaxAge.Seconds())))
+	res.Header().Set("X-Backend-Stale", "true")
+	res.Header().Set("X-Backend-Stale-Age", strconv.FormatInt(int64(result.Age.Seconds()), 10))
+	_, _ = io.WriteString(res, result.Body)
+}
@@ -1,16 +1,32 @@
 package routes
 
 import (
-	"fmt"
-	"io"
+	"encoding/json"
 	"net/http"
 	"skyblock-pv-backend/internal"
 )
 
+type rateLimitResponse struct {
+	Remaining int                 `json:"rate_limit_remaining"`
+	Reset     int                 `json:"rate_limit_reset"`
+	Keys      []internal.KeyState `json:"keys"`
+}
+
 func GetRateLimit(ctx internal.RouteContext, authentication internal.AuthenticationContext, res http.ResponseWriter, _ *http.Request) {
 	if authentication.BypassCache && ctx.Config.Endpoints.RateLimit {
+		keys := ctx.Keys.States()
+		response := rateLimitResponse{Keys: keys}
+		for _, key := range keys {
+			if key.Available && key.Remaining > 0 {
+				response.Remaining += key.Remaining
+			}
+			if key.Reset > 0 && (response.Reset == 0 || key.Reset < response.Reset) {
+				response.Reset = key.Reset
+			}
+		}
+
 		res.Header().Set("Content-Type", "application/json")
-		_, _ = io.WriteString(res, fmt.Sprintf(`{"rate_limit_remaining": %d, "rate_limit_reset": %d}`, internal.RateLimitRemaining, internal.RateLimitReset))
+		_ = json.NewEncoder(res).Encode(response)
 	} else {
 		res.WriteHeader(http.StatusNotFound)
 	}
@@ -3,11 +3,9 @@ package routes
 
 import (
 	"encoding/json"
-	"fmt"
 	"io"
 	"net/http"
 	"skyblock-pv-backend/internal"
-	"skyblock-pv-backend/utils"
 	"strings"
 )
 
@@ -29,19 +27,13 @@ const getSharedData = `
 	select data, profile_id from shared_data where player_id = $1
 `
 
-func GetSharedData(ctx internal.RouteContext, authentication internal.AuthenticationContext, res http.ResponseWriter, req *http.Request) {
+func GetSharedData(ctx internal.RouteContext, _ internal.AuthenticationContext, res http.ResponseWriter, req *http.Request) {
 	playerId := req.PathValue("player_id")
 
 	rows, err := ctx.Pool.Query(*ctx.Context, getSharedData, playerId)
 	if err != nil {
 		res.WriteHeader(http.StatusInternalServerError)
-		fmt.Printf(
-			"[/shared_data/%s] User '%s' with user-agent '%s': %v\n",
-			playerId,
-			authentication.Requester,
-			req.Header.Get("User-Agent"),
-			err,
-		)
+		ctx.Logger.Error("Failed to load shared data", "player_id", playerId, "err", err)
 		return
 	}
 
@@ -54,13 +46,7 @@ func GetSharedData(ctx internal.RouteContext, authentication internal.Authentica
 		err = rows.Scan(&data, &id)
 		if err != nil {
 			res.WriteHeader(http.StatusInternalServerError)
-			fmt.Printf(
-				"[/shared_data/%s] User '%s' with user-agent '%s': %v\n",
-				playerId,
-				authentication.Requester,
-				req.Header.Get("User-Agent"),
-				err,
-			)
+			ctx.Logger.Error("Failed to read shared data", "player_id", playerId, "err", err)
 			return
 		}
 		dataMap[id] = data
@@ -69,13 +55,7 @@ func GetSharedData(ctx internal.RouteContext, authentication internal.Authentica
 	data, err := json.Marshal(dataMap)
 	if err != nil {
 		res.WriteHeader(http.StatusInternalServerError)
-		fmt.Printf(
-			"[/shared_data/%s] User '%s' with user-agent '%s': %v\n",
-			playerId,
-			authentication.Requester,
-			req.Header.Get("User-Agent"),
-			err,
-		)
+		ctx.Logger.Error("Failed to encode shared data", "player_id", playerId, "err", err)
 		return
 	}
 	_, _ = res.Write(data)
@@ -85,28 +65,18 @@ const deleteSharedData = `
 	delete from shared_data where player_id = $1
 `
 
-func DeleteData(ctx internal.RouteContext, authentication internal.AuthenticationContext, res http.ResponseWriter, req *http.Request) {
+func DeleteData(ctx internal.RouteContext, authentication internal.AuthenticationContext, res http.ResponseWriter, _ *http.Request) {
 	playerId := authentication.Requester
 
 	if _, err := ctx.Pool.Exec(*ctx.Context, deleteSharedData, playerId, playerId); err != nil {
 		res.WriteHeader(http.StatusInternalServerError)
-		fmt.Printf(
-			"[/shared_data] Failed to delete player data for '%s' with user-agent '%s': %v\n",
-			authentication.Requester,
-			req.Header.Get("User-Agent"),
-			err,
-		)
+		ctx.Logger.Error("Failed to delete shared data", "err", err)
 	}
 }
 
 func CheckData(ctx internal.RouteContext, player string, profileIds []string) {
 	if _, err := ctx.Pool.Exec(*ctx.Context, deleteUnknownProfiles, player, "{"+strings.Join(profileIds, ",")+"}"); err != nil {
-		fmt.Printf(
-			"[Chore] Failed to execute deletion of unknown profiles for %s (%s): %v\n",
-			player,
-			profileIds,
-			err,
-		)
+		ctx.Logger.Error("Failed to delete shared data of unknown profiles", "player", player, "profiles", profileIds, "err", err)
 	}
 }
 
@@ -122,14 +92,7 @@ func putData(key string, createData func() defaults) func(ctx internal.RouteCont
 
 		if err != nil {
 			res.WriteHeader(http.StatusInternalServerError)
-			fmt.Printf(
-				"[/shared_data/%s/%s] User '%s' with user-agent '%s' failed to put %[2]s: %v\n",
-				profileId,
-				key,
-				playerId,
-				req.Header.Get("User-Agent"),
-				err,
-			)
+			ctx.Logger.Error("Failed to put shared data", "profile_id", profileId, "key", key, "err", err)
 			return
 		}
 
@@ -144,38 +107,16 @@ func putData(key string, createData func() defaults) func(ctx internal.RouteCont
 		data, err = json.Marshal(userData)
 		if err != nil {
 			res.WriteHeader(http.StatusInternalServerError)
-			fmt.Printf(
-				"[/shared_data/%s/%s] User '%s' with user-agent '%s' failed to put %[2]s: %v\n",
-				profileId,
-				key,
-				playerId,
-				req.Header.Get("User-Agent"),
-				err,
-			)
+			ctx.Logger.Error("Failed to put shared data", "profile_id", profileId, "key", key, "err", err)
 			return
 		}
 
 		if _, err := ctx.Pool.Exec(*ctx.Context, addData, playerId, profileId, dbKey, string(data)); err != nil {
 			res.WriteHeader(http.StatusInternalServerError)
-			fmt.Printf(
-				"[/shared_data/%s/%s] User '%s' with user-agent '%s' failed to put %[2]s: %v\n",
-				profileId,
-				key,
-				playerId,
-				req.Header.Get("User-Agent"),
-				err,
-			)
+			ctx.Logger.Error("Failed to put shared data", "profile_id", profileId, "key", key, "err", err)
 			return
 		}
-		if utils.Debug {
-			fmt.Printf(
-				"[/shared_data/%s/%s] Updating shared data for '%s' with user-agent '%s'\n",
-				profileId,
-				key,
-				playerId,
-				req.Header.Get("User-Agent"),
-			)
-		}
+		ctx.Logger.Debug("Updated shared data", "profile_id", profileId, "key", key)
 
 		res.WriteHeader(http.StatusOK)
 	}
@@ -1,58 +1,22 @@
 package routes
 
 import (
-	"fmt"
-	"io"
-	"net/http"
-	"skyblock-pv-backend/internal"
 	"time"
 )
 
 const statusCacheDuration = 5 * time.Minute
 const highProfileStatusCacheDuration = 15 * time.Minute
 const statusFailedCacheDuration = 3 * time.Minute
+const statusStaleCacheDuration = time.Hour
 const statusCacheName = "status"
-const statusHypixelPath = "/v2/status"
+const statusHypixelPath = "/v2/status?uuid=%s"
 
-func GetStatus(ctx internal.RouteContext, authentication internal.AuthenticationContext, res http.ResponseWriter, req *http.Request) {
-	playerId := req.PathValue("id")
-	result, err := ctx.GetFromCache(&authentication, statusCacheName, playerId)
-
-	if err != nil {
-		if ctx.HasErrorCached(statusCacheName, playerId) {
-			res.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-
-		profiles, err := internal.GetFromHypixel(ctx, fmt.Sprintf("%s?uuid=%s", statusHypixelPath, playerId), true)
-		if err == nil && profiles != nil {
-			cacheDuration := statusCacheDuration
-			if ctx.IsHighProfileAccount(playerId) {
-				cacheDuration = highProfileStatusCacheDuration
-			}
-			err = ctx.AddToCache(statusCacheName, playerId, profiles, cacheDuration)
-		} else {
-			cacheError := ctx.AddToErrorCache(statusCacheName, playerId, statusFailedCacheDuration)
-			if cacheError != nil {
-				fmt.Printf("Failed to cache status error: %v\n", cacheError)
-			}
-		}
-
-		if err != nil || profiles == nil {
-			res.WriteHeader(http.StatusInternalServerError)
-			fmt.Printf(
-				"[/status/%s] User '%s' with user-agent '%s' failed to fetch or cache status: %v\n",
-				playerId,
-				authentication.Requester,
-				req.Header.Get("User-Agent"),
-				err,
-			)
-			return
-		}
-		result = *profiles
-	}
-
-	res.Header().Set("Content-Type", "application/json")
-	res.Header().Set("Cache-Control", fmt.Sprintf("max-age=%d", int(statusCacheDuration.Seconds())))
-	_, _ = io.WriteString(res, result)
-}
+var GetStatus = ProxyRoute{
+	CacheName:                statusCacheName,
+	HypixelPath:              statusHypixelPath,
+	PathValue:                "id",
+	CacheDuration:            statusCacheDuration,
+	HighProfileCacheDuration: highProfileStatusCacheDuration,
+	FailedCacheDuration:      statusFailedCacheDuration,
+	StaleDuration:            statusStaleCacheDuration,
+}.Handle
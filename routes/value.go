@@ -0,0 +1,71 @@
+package routes
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"skyblock-pv-backend/internal"
+	"skyblock-pv-backend/networth"
+	"skyblock-pv-backend/utils"
+)
+
+// inventories are rarely larger than a few kilobytes, even backpacks with lots of lore
+const maxValueBodySize = 1 << 20
+
+// ValueRequest contains either a single item (e.g. item_bytes of an auction) or an inventory blob (e.g. inv_contents.data)
+type ValueRequest struct {
+	Item      string `json:"item,omitempty"`
+	Inventory string `json:"inventory,omitempty"`
+}
+
+// PostValue prices an item or an inventory and returns the price components of every item
+func PostValue(ctx internal.RouteContext, res http.ResponseWriter, req *http.Request) {
+	//goland:noinspection GoUnhandledErrorResult
+	defer req.Body.Close()
+	data, err := io.ReadAll(http.MaxBytesReader(res, req.Body, maxValueBodySize))
+	if err != nil {
+		res.WriteHeader(http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	var request ValueRequest
+	if err := json.Unmarshal(data, &request); err != nil || (request.Item == "") == (request.Inventory == "") {
+		res.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	prices, err := getPriceSource(ctx)
+	if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	var value interface{}
+	if request.Item != "" {
+		items, err := utils.DecodeInventory(request.Item)
+		if err != nil || len(items) != 1 {
+			res.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		itemValue := networth.ValueItem(prices, items[0])
+		if itemValue == nil {
+			res.WriteHeader(http.StatusNotFound)
+			return
+		}
+		value = itemValue
+	} else {
+		value, err = networth.ValueInventory(prices, request.Inventory)
+		if err != nil {
+			res.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	data, err = json.Marshal(value)
+	if err != nil {
+		res.WriteHeader(http.StatusInternalServerError)
+		ctx.Logger.Error("Failed to encode value", "err", err)
+		return
+	}
+	writeAuctionResponse(res, string(data))
+}
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// FingerprintAttributes are the attributes a fingerprint can consist of, in the order they appear in the fingerprint
+var FingerprintAttributes = []string{"stars", "modifier", "rarity_upgrades", "enchantments", "gems", "pet_level"}
+
+// Fingerprinter derives pricing keys from items, the key is the sb id followed by the attributes of the item
+// that differ from a clean item, e.g. HYPERION;stars=5;modifier=heroic;enchantments=ultimate_wise:5
+type Fingerprinter struct {
+	attributes   []string
+	enchantments []string
+}
+
+// NewFingerprinter creates a fingerprinter for the given attributes, enchantments lists the enchantments
+// that are part of the fingerprint, ultimate enchantments are always included
+func NewFingerprinter(attributes []string, enchantments []string) (*Fingerprinter, error) {
+	for _, attribute := range attributes {
+		if !slices.Contains(FingerprintAttributes, attribute) {
+			return nil, fmt.Errorf("unknown fingerprint attribute %s", attribute)
+		}
+	}
+	ordered := make([]string, 0, len(attributes))
+	for _, attribute := range FingerprintAttributes {
+		if slices.Contains(attributes, attribute) {
+			ordered = append(ordered, attribute)
+		}
+	}
+	return &Fingerprinter{ordered, enchantments}, nil
+}
+
+// Fingerprint returns the pricing key of the item, this is the sb id if none of the attributes are set
+func (fingerprinter *Fingerprinter) Fingerprint(item Item) *string {
+	sbId := item.GetSbId()
+	if sbId == nil {
+		return nil
+	}
+	attributes := item.Attributes()
+
+	builder := strings.Builder{}
+	builder.WriteString(*sbId)
+	for _, attribute := range fingerprinter.attributes {
+		var value string
+		switch attribute {
+		case "stars":
+			value = item.fingerprintStars()
+		case "modifier":
+			value, _ = ModifierPath.GetString(attributes)
+		case "rarity_upgrades":
+			if upgrades, _ := RarityUpgradesPath.GetInteger(attributes); upgrades > 0 {
+				value = "1"
+			}
+		case "enchantments":
+			value = fingerprinter.fingerprintEnchantments(item)
+		case "gems":
+			value = item.fingerprintGems()
+		case "pet_level":
+			if pet := item.GetPetData(); pet != nil {
+				value = fmt.Sprintf("%d", pet.Level())
+			}
+		}
+		if value != "" {
+			builder.WriteString(FingerprintPart(attribute, value))
+		}
+	}
+
+	fingerprint := builder.String()
+	return &fingerprint
+}
+
+// FingerprintPart formats an attribute of a fingerprint, e.g. the key of a level 100 pet is its sb id
+// followed by FingerprintPart("pet_level", "100")
+func FingerprintPart(attribute string, value string) string {
+	return fmt.Sprintf(";%s=%s", attribute, value)
+}
+
+func (item Item) fingerprintStars() string {
+	attributes := item.Attributes()
+	stars, err := UpgradeLevelPath.GetInteger(attributes)
+	if err != nil {
+		stars, _ = DungeonItemLevelPath.GetInteger(attributes)
+	}
+	if stars <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d", stars)
+}
+
+func (fingerprinter *Fingerprinter) fingerprintEnchantments(item Item) string {
+	parts := make([]string, 0)
+	for _, enchantment := range item.GetEnchantments() {
+		if !strings.HasPrefix(enchantment.Name, "ultimate_") && !slices.Contains(fingerprinter.enchantments, enchantment.Name) {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s:%d", enchantment.Name, enchantment.Level))
+	}
+	slices.Sort(parts)
+	return strings.Join(parts, "/")
+}
+
+// fingerprintGems lists the applied gemstones as type:quality, slots are ignored as they don't change the value
+func (item Item) fingerprintGems() string {
+	parts := make([]string, 0)
+	for _, gemstone := range item.GetGemstones() {
+		parts = append(parts, fmt.Sprintf("%s:%s", gemstone.Type, gemstone.Quality))
+	}
+	slices.Sort(parts)
+	return strings.Join(parts, "/")
+}
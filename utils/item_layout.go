@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"skyblock-pv-backend/utils/nbt"
+	"skyblock-pv-backend/utils/text"
+	"strconv"
+	"strings"
+)
+
+const (
+	// ItemFormatLegacy is the layout before 1.20.5, e.g. {id:"minecraft:diamond_sword",Count:1b,tag:{ExtraAttributes:{...}}}
+	ItemFormatLegacy = "legacy"
+	// ItemFormatComponents is the layout since 1.20.5, e.g. {id:"minecraft:diamond_sword",count:1,components:{"minecraft:custom_data":{...}}}
+	ItemFormatComponents = "components"
+)
+
+// itemLayout are the paths to the parts of an item that moved between the formats
+type itemLayout struct {
+	format     string
+	count      *nbt.Path
+	attributes *nbt.Path
+	names      []*nbt.Path
+	lore       *nbt.Path
+}
+
+var (
+	itemIdPath = nbt.MustCompilePath("id")
+
+	legacyLayout = itemLayout{
+		format:     ItemFormatLegacy,
+		count:      nbt.MustCompilePath("Count"),
+		attributes: nbt.MustCompilePath("tag.ExtraAttributes"),
+		names:      []*nbt.Path{nbt.MustCompilePath("tag.display.Name")},
+		lore:       nbt.MustCompilePath("tag.display.Lore[*]"),
+	}
+	componentLayout = itemLayout{
+		format:     ItemFormatComponents,
+		count:      nbt.MustCompilePath("count"),
+		attributes: nbt.MustCompilePath(`components."minecraft:custom_data"`),
+		names: []*nbt.Path{
+			nbt.MustCompilePath(`components."minecraft:custom_name"`),
+			nbt.MustCompilePath(`components."minecraft:item_name"`),
+		},
+		lore: nbt.MustCompilePath(`components."minecraft:lore"[*]`),
+	}
+)
+
+func (item Item) tag() nbt.WrappedTag {
+	return nbt.CompoundTag(item.Compound)
+}
+
+// layout detects the format of the item, items that have neither components nor a lowercase count are legacy items
+func (item Item) layout() itemLayout {
+	if item.Contains("components") || item.Contains("count") {
+		return componentLayout
+	}
+	return legacyLayout
+}
+
+// Format returns ItemFormatLegacy or ItemFormatComponents
+func (item Item) Format() string {
+	return item.layout().format
+}
+
+// Id returns the minecraft id of the item, numeric ids of old legacy items are returned as number
+func (item Item) Id() string {
+	if id, err := itemIdPath.GetString(item.tag()); err == nil {
+		return id
+	}
+	if id, err := itemIdPath.GetInteger(item.tag()); err == nil {
+		return strconv.FormatInt(id, 10)
+	}
+	return ""
+}
+
+// Count returns the stack size, items without count are a single item
+func (item Item) Count() int {
+	count, err := item.layout().count.GetInteger(item.tag())
+	if err != nil {
+		return 1
+	}
+	return int(count)
+}
+
+// Attributes returns the custom data of the item (ExtraAttributes in the legacy format) to query attribute paths on,
+// items without attributes return an empty compound so every attribute is missing
+func (item Item) Attributes() nbt.WrappedTag {
+	attributes, err := item.layout().attributes.GetCompound(item.tag())
+	if err != nil {
+		return nbt.CompoundTag(nbt.NewCompound())
+	}
+	return nbt.CompoundTag(attributes)
+}
+
+// DisplayName returns the name of the item with § formatting codes, or an empty string if it has no custom name
+func (item Item) DisplayName() string {
+	for _, path := range item.layout().names {
+		if name, err := path.Get(item.tag()); err == nil {
+			return textValue(*name)
+		}
+	}
+	return ""
+}
+
+// Lore returns the lines of the item description in the same way as DisplayName
+func (item Item) Lore() []string {
+	lines := make([]string, 0)
+	for _, match := range item.layout().lore.All(item.tag()) {
+		lines = append(lines, textValue(match.Tag))
+	}
+	return lines
+}
+
+// textValue reads a name or lore line, legacy items store plain strings, components store json text components
+// as string and since 1.21.5 as nbt. Components are converted to formatting codes.
+func textValue(tag nbt.WrappedTag) string {
+	var data []byte
+	if tag.Type() == nbt.TAG_STRING {
+		value := tag.AsString()
+		if !strings.HasPrefix(value, "{") && !strings.HasPrefix(value, "[") && !strings.HasPrefix(value, `"`) {
+			return value
+		}
+		data = []byte(value)
+	} else {
+		data = tag.PlainJSON()
+	}
+
+	spans, err := text.ParseComponent(data)
+	if err != nil {
+		return tag.AsString()
+	}
+	return text.ToLegacy(spans)
+}
+
+// LoreFacts reads the rarity line of the lore
+func (item Item) LoreFacts() text.LoreFacts {
+	return text.ParseLore(item.Lore())
+}
@@ -1,17 +1,56 @@
 package utils
 
 import (
+	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"skyblock-pv-backend/utils/nbt"
+	"strings"
 )
 
 type Item struct {
 	*nbt.Compound
 }
 
-func (item Item) Count() int8 {
-	return item.Compound.Get("Count").AsByteOrDefault(1)
+// paths of attributes, these are relative to Item.Attributes
+var (
+	IdPath               = nbt.MustCompilePath("id")
+	PetInfoPath          = nbt.MustCompilePath("petInfo")
+	EnchantmentsPath     = nbt.MustCompilePath("enchantments.*")
+	RunesPath            = nbt.MustCompilePath("runes.*")
+	GemsPath             = nbt.MustCompilePath("gems")
+	ModifierPath         = nbt.MustCompilePath("modifier")
+	RarityUpgradesPath   = nbt.MustCompilePath("rarity_upgrades")
+	HotPotatoCountPath   = nbt.MustCompilePath("hot_potato_count")
+	UpgradeLevelPath     = nbt.MustCompilePath("upgrade_level")
+	DungeonItemLevelPath = nbt.MustCompilePath("dungeon_item_level")
+)
+
+// DecodeInventory decodes a base64 encoded inventory (e.g. item_bytes or inv_contents.data), empty slots are skipped
+func DecodeInventory(data string) ([]Item, error) {
+	decoded, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, err
+	}
+	tag, err := nbt.Decode(bytes.NewReader(decoded))
+	if err != nil {
+		return nil, err
+	}
+	root := tag.AsCompound()
+	if root == nil || root.Get("i").AsList() == nil {
+		return nil, fmt.Errorf("inventory has no item list")
+	}
+
+	items := make([]Item, 0)
+	for _, value := range root.Get("i").AsList().GetValues() {
+		compound, ok := value.(*nbt.Compound)
+		if !ok || !compound.Contains("id") {
+			continue
+		}
+		items = append(items, Item{Compound: compound})
+	}
+	return items, nil
 }
 
 type PetData struct {
@@ -19,40 +58,54 @@ type PetData struct {
 	Exp         float64 `json:"exp"`
 	Tier        string  `json:"tier"`
 	HideInfo    bool    `json:"hideInfo"`
-	CandiesUsed int16   `json:"candiesUsed"`
+	CandiesUsed int16   `json:"candyUsed"`
 }
 
 func (item Item) GetExtrAttributes() *nbt.Compound {
-	if !item.Contains("tag") {
-		return nil
-	}
-	tag := item.Get("tag").AsCompound()
-	if !tag.Contains("ExtraAttributes") {
+	attributes, err := item.layout().attributes.GetCompound(item.tag())
+	if err != nil {
 		return nil
 	}
-	return tag.Get("ExtraAttributes").AsCompound()
+	return attributes
 }
 
 func (item Item) GetPetData() *PetData {
-	tag := item.GetExtrAttributes()
-	if tag == nil {
+	petJson, err := PetInfoPath.GetString(item.Attributes())
+	if err != nil {
 		return nil
 	}
-	petJson := tag.Get("petInfo").AsString()
 	var petData PetData
-	err := json.Unmarshal([]byte(petJson), &petData)
+	err = json.Unmarshal([]byte(petJson), &petData)
 	if err != nil {
 		return nil
 	}
 	return &petData
 }
 
+type Enchantment struct {
+	Name  string
+	Level int64
+}
+
+// GetEnchantments returns the enchantments in the order they are stored on the item
+func (item Item) GetEnchantments() []Enchantment {
+	enchantments := make([]Enchantment, 0)
+	for _, match := range EnchantmentsPath.All(item.Attributes()) {
+		level, ok := match.Tag.AsInteger()
+		if !ok {
+			continue
+		}
+		enchantments = append(enchantments, Enchantment{match.Keys[0], level})
+	}
+	return enchantments
+}
+
 func (item Item) GetSbId() *string {
-	tag := item.GetExtrAttributes()
-	if tag == nil {
+	attributes := item.Attributes()
+	data, err := IdPath.GetString(attributes)
+	if err != nil {
 		return nil
 	}
-	data := tag.Get("id").AsString()
 	if data == "PET" {
 		petData := item.GetPetData()
 		if petData == nil {
@@ -60,15 +113,55 @@ func (item Item) GetSbId() *string {
 		}
 		data = fmt.Sprintf("pet:%s:%s", petData.Type, petData.Tier)
 	}
+	if data == "ENCHANTED_BOOK" {
+		if enchantments := item.GetEnchantments(); len(enchantments) == 1 {
+			data = fmt.Sprintf("enchantment:%s:%d", enchantments[0].Name, enchantments[0].Level)
+		}
+	}
 	if data == "RUNE" || data == "UNIQUE_RUNE" {
-		runes := tag.Get("runes").AsCompound().GetValues()
+		runes := RunesPath.All(attributes)
 		if len(runes) != 1 {
 			return nil
 		}
-		for id, tier := range runes {
-			data = fmt.Sprintf("rune:%s:%d", id, tier.AsInt())
-			break
+		tier, ok := runes[0].Tag.AsInteger()
+		if !ok {
+			return nil
 		}
+		data = fmt.Sprintf("rune:%s:%d", runes[0].Keys[0], tier)
 	}
 	return &data
 }
+
+type Gemstone struct {
+	Slot    string
+	Type    string
+	Quality string
+}
+
+func (item Item) GetGemstones() []Gemstone {
+	gems, err := GemsPath.GetCompound(item.Attributes())
+	if err != nil {
+		return nil
+	}
+	gemstones := make([]Gemstone, 0)
+	for slot, value := range gems.GetValues() {
+		if slot == "unlocked_slots" || strings.HasSuffix(slot, "_gem") {
+			continue
+		}
+		quality := value.AsString()
+		if compound := value.AsCompound(); compound != nil {
+			quality = compound.Get("quality").AsString()
+		}
+		if quality == "" {
+			continue
+		}
+
+		// universal slots (e.g. COMBAT_0) store the gemstone type separately, other slots are named after it
+		gemType := gems.Get(slot + "_gem").AsString()
+		if gemType == "" {
+			gemType = slot[:max(strings.LastIndex(slot, "_"), 0)]
+		}
+		gemstones = append(gemstones, Gemstone{slot, gemType, quality})
+	}
+	return gemstones
+}
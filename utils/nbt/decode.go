@@ -0,0 +1,393 @@
+package nbt
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+var (
+	ErrTooDeep         = errors.New("nesting is too deep")
+	ErrTooLarge        = errors.New("data is too large")
+	ErrTooManyElements = errors.New("too many elements")
+	ErrUnknownType     = errors.New("unknown tag type")
+	ErrNegativeLength  = errors.New("negative length")
+)
+
+// DecodeError is returned for invalid data, Offset is the position in the uncompressed data where decoding failed
+type DecodeError struct {
+	Offset int64
+	Err    error
+}
+
+func (err *DecodeError) Error() string {
+	return fmt.Sprintf("invalid nbt at offset %d: %v", err.Offset, err.Err)
+}
+
+func (err *DecodeError) Unwrap() error {
+	return err.Err
+}
+
+// Limits protect against data that would take too much memory, a zero limit is not checked
+type Limits struct {
+	// nesting of lists and compounds
+	MaxDepth int
+	// elements of a single list or array
+	MaxElements int
+	// uncompressed size of the data
+	MaxBytes int64
+}
+
+// DefaultLimits are far above anything the hypixel api returns, minecraft uses the same max depth
+var DefaultLimits = Limits{
+	MaxDepth:    512,
+	MaxElements: 1 << 20,
+	MaxBytes:    32 << 20,
+}
+
+// Decoder reads tags with custom limits, the package functions use DefaultLimits
+type Decoder struct {
+	Limits Limits
+}
+
+// Decode reads a named root tag, gzip compressed data is detected automatically. The name of the root is discarded.
+func Decode(reader io.Reader) (*WrappedTag, error) {
+	tag, _, err := Decoder{DefaultLimits}.DecodeNamed(reader)
+	return tag, err
+}
+
+// DecodeNamed reads a named root tag and returns the tag and its name, gzip compressed data is detected automatically
+func DecodeNamed(reader io.Reader) (*WrappedTag, string, error) {
+	return Decoder{DefaultLimits}.DecodeNamed(reader)
+}
+
+// DecodeUnnamed reads a root tag without name as sent over the network since 1.20.2
+func DecodeUnnamed(reader io.Reader) (*WrappedTag, error) {
+	return Decoder{DefaultLimits}.DecodeUnnamed(reader)
+}
+
+func (decoder Decoder) DecodeNamed(reader io.Reader) (*WrappedTag, string, error) {
+	return decoder.decode(reader, true)
+}
+
+func (decoder Decoder) DecodeUnnamed(reader io.Reader) (*WrappedTag, error) {
+	tag, _, err := decoder.decode(reader, false)
+	return tag, err
+}
+
+func (decoder Decoder) decode(reader io.Reader, named bool) (*WrappedTag, string, error) {
+	buffered := bufio.NewReader(reader)
+	// shorter data is read as is and fails in readRoot if it isn't a single TAG_END
+	header, _ := buffered.Peek(2)
+	if len(header) == 2 && header[0] == 0x1f && header[1] == 0x8b {
+		gzipReader, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, "", err
+		}
+		defer gzipReader.Close()
+		buffered = bufio.NewReader(gzipReader)
+	}
+
+	nbtReader := &nbtReader{reader: buffered, limits: decoder.Limits}
+	tag, name, err := nbtReader.readRoot(named)
+	if err != nil {
+		return nil, "", &DecodeError{nbtReader.offset, err}
+	}
+	return tag, name, nil
+}
+
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// nbtReader reads the payloads of tags, numbers are read into a reused buffer instead of allocating for every value
+type nbtReader struct {
+	reader  *bufio.Reader
+	limits  Limits
+	offset  int64
+	depth   int
+	buffer  [8]byte
+	strings []byte
+}
+
+func (reader *nbtReader) readRoot(named bool) (*WrappedTag, string, error) {
+	dataType, err := reader.readByte()
+	if err != nil {
+		return nil, "", err
+	}
+	if dataType == TAG_END {
+		return &WrappedTag{nil, TAG_END}, "", nil
+	}
+	name := ""
+	if named {
+		name, err = reader.readString()
+		if err != nil {
+			return nil, "", err
+		}
+	}
+
+	tag, err := reader.read(dataType)
+	if err != nil {
+		return nil, "", err
+	}
+	return &WrappedTag{tag, dataType}, name, nil
+}
+
+// reserve fails if reading size more bytes would exceed the byte limit, this is checked before allocating
+func (reader *nbtReader) reserve(size int64) error {
+	if reader.limits.MaxBytes > 0 && size > reader.limits.MaxBytes-reader.offset {
+		return ErrTooLarge
+	}
+	return nil
+}
+
+func (reader *nbtReader) readFull(data []byte) error {
+	if err := reader.reserve(int64(len(data))); err != nil {
+		return err
+	}
+	read, err := io.ReadFull(reader.reader, data)
+	reader.offset += int64(read)
+	if err != nil {
+		return unexpectedEOF(err)
+	}
+	return nil
+}
+
+func (reader *nbtReader) readByte() (byte, error) {
+	if err := reader.reserve(1); err != nil {
+		return 0, err
+	}
+	value, err := reader.reader.ReadByte()
+	if err != nil {
+		return 0, unexpectedEOF(err)
+	}
+	reader.offset++
+	return value, nil
+}
+
+func (reader *nbtReader) readShort() (int16, error) {
+	if err := reader.readFull(reader.buffer[:2]); err != nil {
+		return 0, err
+	}
+	return int16(binary.BigEndian.Uint16(reader.buffer[:2])), nil
+}
+
+func (reader *nbtReader) readInt() (int32, error) {
+	if err := reader.readFull(reader.buffer[:4]); err != nil {
+		return 0, err
+	}
+	return int32(binary.BigEndian.Uint32(reader.buffer[:4])), nil
+}
+
+func (reader *nbtReader) readLong() (int64, error) {
+	if err := reader.readFull(reader.buffer[:8]); err != nil {
+		return 0, err
+	}
+	return int64(binary.BigEndian.Uint64(reader.buffer[:8])), nil
+}
+
+func (reader *nbtReader) readFloat() (float32, error) {
+	value, err := reader.readInt()
+	return math.Float32frombits(uint32(value)), err
+}
+
+func (reader *nbtReader) readDouble() (float64, error) {
+	value, err := reader.readLong()
+	return math.Float64frombits(uint64(value)), err
+}
+
+// readLength reads the length of a list or array and checks it against the limits, elementSize is the
+// least amount of bytes a single element takes so huge lengths fail before anything is allocated
+func (reader *nbtReader) readLength(elementSize int64) (int, error) {
+	length, err := reader.readInt()
+	if err != nil {
+		return 0, err
+	}
+	if length < 0 {
+		return 0, ErrNegativeLength
+	}
+	if reader.limits.MaxElements > 0 && int(length) > reader.limits.MaxElements {
+		return 0, ErrTooManyElements
+	}
+	if err := reader.reserve(int64(length) * elementSize); err != nil {
+		return 0, err
+	}
+	return int(length), nil
+}
+
+func (reader *nbtReader) readByteArray() ([]byte, error) {
+	length, err := reader.readLength(1)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, length)
+	return data, reader.readFull(data)
+}
+
+func (reader *nbtReader) readString() (string, error) {
+	length, err := reader.readShort()
+	if err != nil {
+		return "", err
+	}
+	size := int(uint16(length))
+	if cap(reader.strings) < size {
+		reader.strings = make([]byte, size)
+	}
+	data := reader.strings[:size]
+	if err := reader.readFull(data); err != nil {
+		return "", err
+	}
+	return decodeModifiedUtf8(data), nil
+}
+
+func (reader *nbtReader) enter() error {
+	reader.depth++
+	if reader.limits.MaxDepth > 0 && reader.depth > reader.limits.MaxDepth {
+		return ErrTooDeep
+	}
+	return nil
+}
+
+func (reader *nbtReader) readList() (*List, error) {
+	if err := reader.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { reader.depth-- }()
+
+	dataType, err := reader.readByte()
+	if err != nil {
+		return nil, err
+	}
+	length, err := reader.readLength(payloadSize(dataType))
+	if err != nil {
+		return nil, err
+	}
+	if length > 0 && (dataType == TAG_END || dataType > TAG_LONG_ARRAY) {
+		return nil, ErrUnknownType
+	}
+
+	list := List{make([]Tag, length), dataType}
+	for i := range length {
+		list.values[i], err = reader.read(dataType)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &list, nil
+}
+
+// payloadSize is the least amount of bytes a payload of the type takes
+func payloadSize(dataType byte) int64 {
+	switch dataType {
+	case TAG_SHORT:
+		return 2
+	case TAG_INT, TAG_FLOAT, TAG_BYTE_ARRAY, TAG_INT_ARRAY, TAG_LONG_ARRAY:
+		return 4
+	case TAG_LONG, TAG_DOUBLE:
+		return 8
+	case TAG_LIST:
+		return 5
+	case TAG_STRING:
+		return 2
+	}
+	return 1
+}
+
+func (reader *nbtReader) read(dataType byte) (Tag, error) {
+	switch dataType {
+	case TAG_BYTE:
+		return reader.readByte()
+	case TAG_SHORT:
+		return reader.readShort()
+	case TAG_INT:
+		return reader.readInt()
+	case TAG_LONG:
+		return reader.readLong()
+	case TAG_FLOAT:
+		return reader.readFloat()
+	case TAG_DOUBLE:
+		return reader.readDouble()
+	case TAG_BYTE_ARRAY:
+		return reader.readByteArray()
+	case TAG_STRING:
+		value, err := reader.readString()
+		if err != nil {
+			return nil, err
+		}
+		return &value, nil
+	case TAG_LIST:
+		return reader.readList()
+	case TAG_COMPOUND:
+		return reader.readCompound()
+	case TAG_INT_ARRAY:
+		return reader.readIntArray()
+	case TAG_LONG_ARRAY:
+		return reader.readLongArray()
+	}
+	return nil, fmt.Errorf("%w %d", ErrUnknownType, dataType)
+}
+
+func (reader *nbtReader) readCompound() (*Compound, error) {
+	if err := reader.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { reader.depth-- }()
+
+	data := NewCompound()
+	for {
+		dataType, err := reader.readByte()
+		if err != nil {
+			return nil, err
+		}
+		if dataType == TAG_END {
+			break
+		}
+
+		name, err := reader.readString()
+		if err != nil {
+			return nil, err
+		}
+
+		tag, err := reader.read(dataType)
+		if err != nil {
+			return nil, err
+		}
+		data.Set(name, WrappedTag{tag, dataType})
+	}
+	return data, nil
+}
+
+func (reader *nbtReader) readIntArray() ([]int32, error) {
+	length, err := reader.readLength(4)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]int32, length)
+	for i := range data {
+		if data[i], err = reader.readInt(); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+func (reader *nbtReader) readLongArray() ([]int64, error) {
+	length, err := reader.readLength(8)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]int64, length)
+	for i := range data {
+		if data[i], err = reader.readLong(); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
@@ -0,0 +1,219 @@
+package nbt
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestDecodeFixtures(t *testing.T) {
+	for i, blob := range fixtureBlobs(t) {
+		tag, err := Decode(bytes.NewReader(blob))
+		if err != nil {
+			t.Fatalf("item %d: %v", i, err)
+		}
+		if tag.Type() != TAG_COMPOUND {
+			t.Errorf("item %d: root is %s", i, TypeName(tag.Type()))
+		}
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits Limits
+		data   []byte
+		err    error
+		offset int64
+	}{
+		{
+			name:   "nested compound over max depth",
+			limits: Limits{MaxDepth: 1},
+			// {a:{}}, the inner compound is entered after its name
+			data:   []byte{TAG_COMPOUND, 0, 0, TAG_COMPOUND, 0, 1, 'a', TAG_END, TAG_END},
+			err:    ErrTooDeep,
+			offset: 7,
+		},
+		{
+			name:   "nested list over max depth",
+			limits: Limits{MaxDepth: 2},
+			// {a:[[]]}
+			data:   []byte{TAG_COMPOUND, 0, 0, TAG_LIST, 0, 1, 'a', TAG_LIST, 0, 0, 0, 1, TAG_END, 0, 0, 0, 0, TAG_END},
+			err:    ErrTooDeep,
+			offset: 12,
+		},
+		{
+			name:   "byte array over max elements",
+			limits: Limits{MaxElements: 2},
+			// {b:[B;1B,2B,3B]}, the length is checked after reading it
+			data:   []byte{TAG_COMPOUND, 0, 0, TAG_BYTE_ARRAY, 0, 1, 'b', 0, 0, 0, 3, 1, 2, 3, TAG_END},
+			err:    ErrTooManyElements,
+			offset: 11,
+		},
+		{
+			name:   "list over max elements",
+			limits: Limits{MaxElements: 1},
+			// {l:[1b,2b]}
+			data:   []byte{TAG_COMPOUND, 0, 0, TAG_LIST, 0, 1, 'l', TAG_BYTE, 0, 0, 0, 2, 1, 2, TAG_END},
+			err:    ErrTooManyElements,
+			offset: 12,
+		},
+		{
+			name:   "array over max bytes",
+			limits: Limits{MaxBytes: 20},
+			// a root array claiming 100 bytes fails before allocating them
+			data:   []byte{TAG_BYTE_ARRAY, 0, 0, 0, 0, 0, 100},
+			err:    ErrTooLarge,
+			offset: 7,
+		},
+		{
+			name:   "string over max bytes",
+			limits: Limits{MaxBytes: 5},
+			data:   []byte{TAG_COMPOUND, 0, 10, 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', TAG_END},
+			err:    ErrTooLarge,
+			offset: 3,
+		},
+		{
+			name:   "long array over max bytes",
+			limits: Limits{MaxBytes: 64},
+			// 16 longs need 128 bytes
+			data:   []byte{TAG_LONG_ARRAY, 0, 0, 0, 0, 0, 16},
+			err:    ErrTooLarge,
+			offset: 7,
+		},
+		{
+			name:   "negative length",
+			limits: DefaultLimits,
+			data:   []byte{TAG_INT_ARRAY, 0, 0, 0xff, 0xff, 0xff, 0xff},
+			err:    ErrNegativeLength,
+			offset: 7,
+		},
+		{
+			name:   "unknown type",
+			limits: DefaultLimits,
+			data:   []byte{TAG_COMPOUND, 0, 0, 13, 0, 1, 'x', TAG_END},
+			err:    ErrUnknownType,
+			offset: 7,
+		},
+		{
+			name:   "truncated value",
+			limits: DefaultLimits,
+			// {a:<missing byte>}
+			data:   []byte{TAG_COMPOUND, 0, 0, TAG_BYTE, 0, 1, 'a'},
+			err:    io.ErrUnexpectedEOF,
+			offset: 7,
+		},
+		{
+			name:   "truncated int",
+			limits: DefaultLimits,
+			// {a:<2 of 4 bytes>}, the offset counts the bytes that were read
+			data:   []byte{TAG_COMPOUND, 0, 0, TAG_INT, 0, 1, 'a', 0, 0},
+			err:    io.ErrUnexpectedEOF,
+			offset: 9,
+		},
+		{
+			name:   "missing end of compound",
+			limits: DefaultLimits,
+			data:   []byte{TAG_COMPOUND, 0, 0, TAG_BYTE, 0, 1, 'a', 1},
+			err:    io.ErrUnexpectedEOF,
+			offset: 8,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := Decoder{test.limits}.DecodeNamed(bytes.NewReader(test.data))
+			var decodeError *DecodeError
+			if !errors.As(err, &decodeError) {
+				t.Fatalf("expected a DecodeError, got %v", err)
+			}
+			if !errors.Is(err, test.err) {
+				t.Errorf("expected %v, got %v", test.err, decodeError.Err)
+			}
+			if decodeError.Offset != test.offset {
+				t.Errorf("expected offset %d, got %d", test.offset, decodeError.Offset)
+			}
+		})
+	}
+}
+
+func TestDecodeTruncatedFixtures(t *testing.T) {
+	for i, raw := range fixtureItems(t) {
+		for _, length := range []int{1, len(raw) / 2, len(raw) - 1} {
+			_, err := Decode(bytes.NewReader(raw[:length]))
+			var decodeError *DecodeError
+			if !errors.As(err, &decodeError) || !errors.Is(err, io.ErrUnexpectedEOF) {
+				t.Errorf("item %d cut to %d bytes: expected an unexpected EOF, got %v", i, length, err)
+			} else if decodeError.Offset != int64(length) {
+				t.Errorf("item %d cut to %d bytes: failed at offset %d", i, length, decodeError.Offset)
+			}
+		}
+	}
+}
+
+func FuzzDecode(f *testing.F) {
+	for _, blob := range fixtureBlobs(f) {
+		f.Add(blob)
+	}
+	for _, raw := range fixtureItems(f) {
+		f.Add(raw)
+	}
+
+	limits := Limits{MaxDepth: 64, MaxElements: 1 << 12, MaxBytes: 1 << 20}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		tag, name, err := Decoder{limits}.DecodeNamed(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+
+		// everything that decodes has to encode and decode to the same tag again
+		encoded := bytes.Buffer{}
+		if err := EncodeNamed(&encoded, name, tag); err != nil {
+			t.Fatalf("failed to encode decoded tag: %v", err)
+		}
+		decoded, decodedName, err := Decoder{limits}.DecodeNamed(&encoded)
+		if err != nil {
+			t.Fatalf("failed to decode encoded tag: %v", err)
+		}
+		if decodedName != name || decoded.SNBT() != tag.SNBT() {
+			t.Fatalf("%q %s was decoded as %q %s", name, tag.SNBT(), decodedName, decoded.SNBT())
+		}
+	})
+}
+
+func BenchmarkDecode(b *testing.B) {
+	blobs := fixtureBlobs(b)
+	size := 0
+	for _, blob := range blobs {
+		size += len(blob)
+	}
+	b.SetBytes(int64(size))
+	b.ReportAllocs()
+
+	for b.Loop() {
+		for _, blob := range blobs {
+			if _, err := Decode(bytes.NewReader(blob)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkDecodeUncompressed(b *testing.B) {
+	items := fixtureItems(b)
+	size := 0
+	for _, item := range items {
+		size += len(item)
+	}
+	b.SetBytes(int64(size))
+	b.ReportAllocs()
+
+	for b.Loop() {
+		for _, item := range items {
+			if _, err := Decode(bytes.NewReader(item)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
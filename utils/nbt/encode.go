@@ -0,0 +1,166 @@
+package nbt
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Encode writes the tag as root with an empty name, the format used by item data of the hypixel api.
+// Decoding and encoding data written by minecraft results in exactly the same bytes.
+func Encode(writer io.Writer, tag *WrappedTag) error {
+	return EncodeNamed(writer, "", tag)
+}
+
+func EncodeNamed(writer io.Writer, name string, tag *WrappedTag) error {
+	return encode(writer, &name, tag)
+}
+
+// EncodeUnnamed writes the tag as root without name as sent over the network since 1.20.2
+func EncodeUnnamed(writer io.Writer, tag *WrappedTag) error {
+	return encode(writer, nil, tag)
+}
+
+// EncodeGZip writes the tag as named root and compresses it with gzip, e.g. for inventory data
+func EncodeGZip(writer io.Writer, name string, tag *WrappedTag) error {
+	gzipWriter := gzip.NewWriter(writer)
+	if err := EncodeNamed(gzipWriter, name, tag); err != nil {
+		_ = gzipWriter.Close()
+		return err
+	}
+	return gzipWriter.Close()
+}
+
+func encode(writer io.Writer, name *string, tag *WrappedTag) error {
+	nbtWriter := nbtWriter{bufio.NewWriter(writer)}
+	if err := nbtWriter.writeByte(tag.tagType); err != nil {
+		return err
+	}
+	if tag.tagType != TAG_END {
+		if name != nil {
+			if err := nbtWriter.writeString(*name); err != nil {
+				return err
+			}
+		}
+		if err := nbtWriter.write(tag.tagType, tag.Tag); err != nil {
+			return err
+		}
+	}
+	return nbtWriter.Flush()
+}
+
+type nbtWriter struct {
+	*bufio.Writer
+}
+
+func (writer nbtWriter) writeByte(value byte) error {
+	return writer.WriteByte(value)
+}
+
+func (writer nbtWriter) writeShort(value int16) error {
+	return binary.Write(writer, binary.BigEndian, value)
+}
+
+func (writer nbtWriter) writeInt(value int32) error {
+	return binary.Write(writer, binary.BigEndian, value)
+}
+
+func (writer nbtWriter) writeLong(value int64) error {
+	return binary.Write(writer, binary.BigEndian, value)
+}
+
+func (writer nbtWriter) writeLength(length int) error {
+	if length > math.MaxInt32 {
+		return fmt.Errorf("length %d is too large", length)
+	}
+	return writer.writeInt(int32(length))
+}
+
+func (writer nbtWriter) writeString(value string) error {
+	data := encodeModifiedUtf8(value)
+	if len(data) > math.MaxUint16 {
+		return fmt.Errorf("string of %d bytes is too long", len(data))
+	}
+	if err := writer.writeShort(int16(uint16(len(data)))); err != nil {
+		return err
+	}
+	_, err := writer.Write(data)
+	return err
+}
+
+func (writer nbtWriter) writeList(list *List) error {
+	if err := writer.writeByte(list.DataType); err != nil {
+		return err
+	}
+	if err := writer.writeLength(len(list.values)); err != nil {
+		return err
+	}
+	for _, value := range list.values {
+		if err := writer.write(list.DataType, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (writer nbtWriter) writeCompound(compound *Compound) error {
+	for _, key := range compound.keys {
+		tag := compound.backing[key]
+		if err := writer.writeByte(tag.tagType); err != nil {
+			return err
+		}
+		if err := writer.writeString(key); err != nil {
+			return err
+		}
+		if err := writer.write(tag.tagType, tag.Tag); err != nil {
+			return err
+		}
+	}
+	return writer.writeByte(TAG_END)
+}
+
+func (writer nbtWriter) write(dataType byte, tag Tag) error {
+	switch dataType {
+	case TAG_BYTE:
+		return writer.writeByte(tag.(byte))
+	case TAG_SHORT:
+		return writer.writeShort(tag.(int16))
+	case TAG_INT:
+		return writer.writeInt(tag.(int32))
+	case TAG_LONG:
+		return writer.writeLong(tag.(int64))
+	case TAG_FLOAT:
+		return writer.writeInt(int32(math.Float32bits(tag.(float32))))
+	case TAG_DOUBLE:
+		return writer.writeLong(int64(math.Float64bits(tag.(float64))))
+	case TAG_BYTE_ARRAY:
+		data := tag.([]byte)
+		if err := writer.writeLength(len(data)); err != nil {
+			return err
+		}
+		_, err := writer.Write(data)
+		return err
+	case TAG_STRING:
+		return writer.writeString(*tag.(*string))
+	case TAG_LIST:
+		return writer.writeList(tag.(*List))
+	case TAG_COMPOUND:
+		return writer.writeCompound(tag.(*Compound))
+	case TAG_INT_ARRAY:
+		data := tag.([]int32)
+		if err := writer.writeLength(len(data)); err != nil {
+			return err
+		}
+		return binary.Write(writer, binary.BigEndian, data)
+	case TAG_LONG_ARRAY:
+		data := tag.([]int64)
+		if err := writer.writeLength(len(data)); err != nil {
+			return err
+		}
+		return binary.Write(writer, binary.BigEndian, data)
+	}
+	return fmt.Errorf("unknown tag type %d", dataType)
+}
@@ -0,0 +1,173 @@
+package nbt
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fixtureBlobs returns the gzip compressed item_bytes of the auctions served by the hypixel mock
+func fixtureBlobs(t testing.TB) [][]byte {
+	t.Helper()
+	files, err := filepath.Glob("../../internal/hypixelmock/fixtures/auctions/*.json")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no auction fixtures found: %v", err)
+	}
+
+	blobs := make([][]byte, 0)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var page struct {
+			Auctions []struct {
+				ItemBytes string `json:"item_bytes"`
+			} `json:"auctions"`
+		}
+		if err := json.Unmarshal(data, &page); err != nil {
+			t.Fatal(err)
+		}
+		for _, auction := range page.Auctions {
+			compressed, err := base64.StdEncoding.DecodeString(auction.ItemBytes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			blobs = append(blobs, compressed)
+		}
+	}
+	return blobs
+}
+
+// fixtureItems returns the uncompressed fixture blobs
+func fixtureItems(t testing.TB) [][]byte {
+	t.Helper()
+	items := make([][]byte, 0)
+	for _, compressed := range fixtureBlobs(t) {
+		reader, err := gzip.NewReader(bytes.NewReader(compressed))
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		items = append(items, raw)
+	}
+	return items
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	for i, raw := range fixtureItems(t) {
+		tag, name, err := DecodeNamed(bytes.NewReader(raw))
+		if err != nil {
+			t.Fatalf("item %d: %v", i, err)
+		}
+		encoded := bytes.Buffer{}
+		if err := EncodeNamed(&encoded, name, tag); err != nil {
+			t.Fatalf("item %d: %v", i, err)
+		}
+		if !bytes.Equal(encoded.Bytes(), raw) {
+			t.Errorf("item %d: encoded %d bytes differ from the %d decoded bytes", i, encoded.Len(), len(raw))
+		}
+	}
+}
+
+func TestSNBTRoundTrip(t *testing.T) {
+	for i, raw := range fixtureItems(t) {
+		tag, err := Decode(bytes.NewReader(raw))
+		if err != nil {
+			t.Fatalf("item %d: %v", i, err)
+		}
+		formatted := tag.SNBT()
+		parsed, err := ParseSNBT(formatted)
+		if err != nil {
+			t.Fatalf("item %d: %v", i, err)
+		}
+		if reformatted := parsed.SNBT(); reformatted != formatted {
+			t.Errorf("item %d: %s was formatted as %s after parsing", i, formatted, reformatted)
+		}
+
+		// the parsed tag encodes to the same bytes as the original
+		encoded := bytes.Buffer{}
+		if err := Encode(&encoded, parsed); err != nil {
+			t.Fatalf("item %d: %v", i, err)
+		}
+		if !bytes.Equal(encoded.Bytes(), raw) {
+			t.Errorf("item %d: parsed snbt encodes to different bytes", i)
+		}
+	}
+}
+
+func TestParseSNBTLists(t *testing.T) {
+	tests := []struct {
+		input    string
+		tagType  byte
+		expected string
+	}{
+		{`[";"]`, TAG_LIST, `[";"]`},
+		{`["B;"]`, TAG_LIST, `["B;"]`},
+		{`[ "a" , "b" ]`, TAG_LIST, `["a","b"]`},
+		{`[B;1B,-2B]`, TAG_BYTE_ARRAY, `[B;1B,-2B]`},
+		{`[I;1,2]`, TAG_INT_ARRAY, `[I;1,2]`},
+		{`[L;]`, TAG_LONG_ARRAY, `[L;]`},
+	}
+	for _, test := range tests {
+		tag, err := ParseSNBT(test.input)
+		if err != nil {
+			t.Errorf("%s: %v", test.input, err)
+			continue
+		}
+		if tag.Type() != test.tagType {
+			t.Errorf("%s: parsed as %s, expected %s", test.input, TypeName(tag.Type()), TypeName(test.tagType))
+		}
+		if formatted := tag.SNBT(); formatted != test.expected {
+			t.Errorf("%s: formatted as %s, expected %s", test.input, formatted, test.expected)
+		}
+	}
+}
+
+func TestSNBTNonFiniteFloats(t *testing.T) {
+	tests := []struct {
+		tag      WrappedTag
+		expected string
+		check    func(value float64) bool
+	}{
+		{FloatTag(float32(math.NaN())), "NaNf", math.IsNaN},
+		{FloatTag(float32(math.Inf(1))), "Infinityf", func(value float64) bool { return math.IsInf(value, 1) }},
+		{DoubleTag(math.Inf(-1)), "-Infinityd", func(value float64) bool { return math.IsInf(value, -1) }},
+		{DoubleTag(math.NaN()), "NaNd", math.IsNaN},
+	}
+	for _, test := range tests {
+		formatted := test.tag.SNBT()
+		if formatted != test.expected {
+			t.Errorf("formatted as %s, expected %s", formatted, test.expected)
+		}
+		parsed, err := ParseSNBT(formatted)
+		if err != nil {
+			t.Fatalf("%s: %v", formatted, err)
+		}
+		if parsed.Type() != test.tag.Type() {
+			t.Fatalf("%s: parsed as %s", formatted, TypeName(parsed.Type()))
+		}
+		value := parsed.AsDouble
+		if parsed.Type() == TAG_FLOAT {
+			value = func() float64 { return float64(parsed.AsFloat()) }
+		}
+		if !test.check(value()) {
+			t.Errorf("%s: parsed as %v", formatted, value())
+		}
+	}
+
+	// strings with the same text stay strings
+	parsed, err := ParseSNBT(`"NaNf"`)
+	if err != nil || parsed.Type() != TAG_STRING {
+		t.Errorf("quoted NaNf was not parsed as string: %v", err)
+	}
+}
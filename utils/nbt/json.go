@@ -0,0 +1,327 @@
+package nbt
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// there are two json mappings, the typed mapping keeps every tag as {"type":"int","value":1} and can be parsed back
+// without losing anything, lists additionally have an element_type so empty lists keep their type. Longs are strings in
+// the typed mapping as javascript can't represent them, floats that json can't represent are "NaN", "Infinity" or
+// "-Infinity". The plain mapping only keeps the values, compounds become objects and lists and arrays become arrays.
+
+var typeNames = map[byte]string{
+	TAG_END:        "end",
+	TAG_BYTE:       "byte",
+	TAG_SHORT:      "short",
+	TAG_INT:        "int",
+	TAG_LONG:       "long",
+	TAG_FLOAT:      "float",
+	TAG_DOUBLE:     "double",
+	TAG_BYTE_ARRAY: "byte_array",
+	TAG_STRING:     "string",
+	TAG_LIST:       "list",
+	TAG_COMPOUND:   "compound",
+	TAG_INT_ARRAY:  "int_array",
+	TAG_LONG_ARRAY: "long_array",
+}
+
+// TypedJSON returns the lossless json mapping of the tag, ParseTypedJSON reads it back
+func (tag WrappedTag) TypedJSON() []byte {
+	buffer := bytes.Buffer{}
+	writeJSON(&buffer, tag.tagType, tag.Tag, true)
+	return buffer.Bytes()
+}
+
+// PlainJSON returns the values of the tag without type information
+func (tag WrappedTag) PlainJSON() []byte {
+	buffer := bytes.Buffer{}
+	writeJSON(&buffer, tag.tagType, tag.Tag, false)
+	return buffer.Bytes()
+}
+
+func writeJSON(buffer *bytes.Buffer, tagType byte, tag Tag, typed bool) {
+	if typed {
+		buffer.WriteString(`{"type":"` + typeNames[tagType] + `"`)
+		if tagType == TAG_LIST {
+			buffer.WriteString(`,"element_type":"` + typeNames[tag.(*List).DataType] + `"`)
+		}
+		buffer.WriteString(`,"value":`)
+	}
+
+	switch tagType {
+	case TAG_END:
+		buffer.WriteString("null")
+	case TAG_BYTE:
+		buffer.WriteString(strconv.Itoa(int(int8(tag.(byte)))))
+	case TAG_SHORT:
+		buffer.WriteString(strconv.Itoa(int(tag.(int16))))
+	case TAG_INT:
+		buffer.WriteString(strconv.Itoa(int(tag.(int32))))
+	case TAG_LONG:
+		writeLong(buffer, tag.(int64), typed)
+	case TAG_FLOAT:
+		writeFloat(buffer, float64(tag.(float32)), 32, typed)
+	case TAG_DOUBLE:
+		writeFloat(buffer, tag.(float64), 64, typed)
+	case TAG_BYTE_ARRAY:
+		buffer.WriteByte('[')
+		for i, value := range tag.([]byte) {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			buffer.WriteString(strconv.Itoa(int(int8(value))))
+		}
+		buffer.WriteByte(']')
+	case TAG_STRING:
+		writeJSONString(buffer, *tag.(*string))
+	case TAG_LIST:
+		list := tag.(*List)
+		buffer.WriteByte('[')
+		for i, value := range list.values {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			writeJSON(buffer, list.DataType, value, typed)
+		}
+		buffer.WriteByte(']')
+	case TAG_COMPOUND:
+		compound := tag.(*Compound)
+		buffer.WriteByte('{')
+		for i, key := range compound.keys {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			writeJSONString(buffer, key)
+			buffer.WriteByte(':')
+			value := compound.backing[key]
+			writeJSON(buffer, value.tagType, value.Tag, typed)
+		}
+		buffer.WriteByte('}')
+	case TAG_INT_ARRAY:
+		buffer.WriteByte('[')
+		for i, value := range tag.([]int32) {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			buffer.WriteString(strconv.Itoa(int(value)))
+		}
+		buffer.WriteByte(']')
+	case TAG_LONG_ARRAY:
+		buffer.WriteByte('[')
+		for i, value := range tag.([]int64) {
+			if i > 0 {
+				buffer.WriteByte(',')
+			}
+			writeLong(buffer, value, typed)
+		}
+		buffer.WriteByte(']')
+	}
+
+	if typed {
+		buffer.WriteByte('}')
+	}
+}
+
+func writeLong(buffer *bytes.Buffer, value int64, typed bool) {
+	if typed {
+		buffer.WriteString(`"` + strconv.FormatInt(value, 10) + `"`)
+	} else {
+		buffer.WriteString(strconv.FormatInt(value, 10))
+	}
+}
+
+func writeFloat(buffer *bytes.Buffer, value float64, bitSize int, typed bool) {
+	switch {
+	case math.IsNaN(value) && typed:
+		buffer.WriteString(`"NaN"`)
+	case math.IsInf(value, 1) && typed:
+		buffer.WriteString(`"Infinity"`)
+	case math.IsInf(value, -1) && typed:
+		buffer.WriteString(`"-Infinity"`)
+	case math.IsNaN(value) || math.IsInf(value, 0):
+		buffer.WriteString("null")
+	default:
+		buffer.WriteString(strconv.FormatFloat(value, 'g', -1, bitSize))
+	}
+}
+
+func writeJSONString(buffer *bytes.Buffer, value string) {
+	// encoding a string can't fail
+	data, _ := json.Marshal(value)
+	buffer.Write(data)
+}
+
+type typedTag struct {
+	Type        string          `json:"type"`
+	ElementType string          `json:"element_type"`
+	Value       json.RawMessage `json:"value"`
+}
+
+// ParseTypedJSON reads the typed json mapping created by WrappedTag.TypedJSON
+func ParseTypedJSON(data []byte) (*WrappedTag, error) {
+	var typed typedTag
+	if err := json.Unmarshal(data, &typed); err != nil {
+		return nil, err
+	}
+	tagType, ok := typeByName(typed.Type)
+	if !ok {
+		return nil, fmt.Errorf("unknown tag type %q", typed.Type)
+	}
+
+	var tag WrappedTag
+	var err error
+	switch tagType {
+	case TAG_END:
+		tag = WrappedTag{nil, TAG_END}
+	case TAG_BYTE:
+		var value int8
+		err = json.Unmarshal(typed.Value, &value)
+		tag = ByteTag(value)
+	case TAG_SHORT:
+		var value int16
+		err = json.Unmarshal(typed.Value, &value)
+		tag = ShortTag(value)
+	case TAG_INT:
+		var value int32
+		err = json.Unmarshal(typed.Value, &value)
+		tag = IntTag(value)
+	case TAG_LONG:
+		var value int64
+		value, err = parseLong(typed.Value)
+		tag = LongTag(value)
+	case TAG_FLOAT:
+		var value float64
+		value, err = parseFloat(typed.Value, 32)
+		tag = FloatTag(float32(value))
+	case TAG_DOUBLE:
+		var value float64
+		value, err = parseFloat(typed.Value, 64)
+		tag = DoubleTag(value)
+	case TAG_BYTE_ARRAY:
+		var values []int8
+		err = json.Unmarshal(typed.Value, &values)
+		data := make([]byte, len(values))
+		for i, value := range values {
+			data[i] = byte(value)
+		}
+		tag = ByteArrayTag(data)
+	case TAG_STRING:
+		var value string
+		err = json.Unmarshal(typed.Value, &value)
+		tag = StringTag(value)
+	case TAG_LIST:
+		var list *List
+		list, err = parseTypedList(typed)
+		tag = ListTag(list)
+	case TAG_COMPOUND:
+		var compound *Compound
+		compound, err = parseTypedCompound(typed.Value)
+		tag = CompoundTag(compound)
+	case TAG_INT_ARRAY:
+		var values []int32
+		err = json.Unmarshal(typed.Value, &values)
+		tag = IntArrayTag(values)
+	case TAG_LONG_ARRAY:
+		var values []json.RawMessage
+		err = json.Unmarshal(typed.Value, &values)
+		data := make([]int64, len(values))
+		for i := 0; err == nil && i < len(values); i++ {
+			data[i], err = parseLong(values[i])
+		}
+		tag = LongArrayTag(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", typed.Type, err)
+	}
+	return &tag, nil
+}
+
+func typeByName(name string) (byte, bool) {
+	for tagType, typeName := range typeNames {
+		if typeName == name {
+			return tagType, true
+		}
+	}
+	return 0, false
+}
+
+// parseLong accepts longs as string and as number
+func parseLong(data json.RawMessage) (int64, error) {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		return strconv.ParseInt(text, 10, 64)
+	}
+	var value int64
+	err := json.Unmarshal(data, &value)
+	return value, err
+}
+
+func parseFloat(data json.RawMessage, bitSize int) (float64, error) {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		switch text {
+		case "NaN":
+			return math.NaN(), nil
+		case "Infinity":
+			return math.Inf(1), nil
+		case "-Infinity":
+			return math.Inf(-1), nil
+		}
+		return strconv.ParseFloat(text, bitSize)
+	}
+	var value float64
+	err := json.Unmarshal(data, &value)
+	return value, err
+}
+
+func parseTypedList(typed typedTag) (*List, error) {
+	elementType, ok := typeByName(typed.ElementType)
+	if !ok {
+		return nil, fmt.Errorf("unknown element type %q", typed.ElementType)
+	}
+	var values []json.RawMessage
+	if err := json.Unmarshal(typed.Value, &values); err != nil {
+		return nil, err
+	}
+	list := NewList(elementType)
+	for _, value := range values {
+		tag, err := ParseTypedJSON(value)
+		if err != nil {
+			return nil, err
+		}
+		if err := list.Add(*tag); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+// parseTypedCompound reads the entries with a decoder to keep their order
+func parseTypedCompound(data json.RawMessage) (*Compound, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, fmt.Errorf("expected object")
+	}
+	compound := NewCompound()
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		key := token.(string)
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+		tag, err := ParseTypedJSON(value)
+		if err != nil {
+			return nil, err
+		}
+		compound.Set(key, *tag)
+	}
+	return compound, nil
+}
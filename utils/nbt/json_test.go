@@ -0,0 +1,109 @@
+package nbt
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestTypedJSONRoundTrip(t *testing.T) {
+	for i, raw := range fixtureItems(t) {
+		tag, name, err := DecodeNamed(bytes.NewReader(raw))
+		if err != nil {
+			t.Fatalf("item %d: %v", i, err)
+		}
+		typed := tag.TypedJSON()
+		parsed, err := ParseTypedJSON(typed)
+		if err != nil {
+			t.Fatalf("item %d: %v", i, err)
+		}
+		if retyped := parsed.TypedJSON(); !bytes.Equal(retyped, typed) {
+			t.Errorf("item %d: %s was written as %s after parsing", i, typed, retyped)
+		}
+
+		encoded := bytes.Buffer{}
+		if err := EncodeNamed(&encoded, name, parsed); err != nil {
+			t.Fatalf("item %d: %v", i, err)
+		}
+		if !bytes.Equal(encoded.Bytes(), raw) {
+			t.Errorf("item %d: parsed json encodes to different bytes", i)
+		}
+	}
+}
+
+func TestTypedJSON(t *testing.T) {
+	list := NewList(TAG_LONG)
+	_ = list.Add(LongTag(math.MaxInt64))
+	_ = list.Add(LongTag(math.MinInt64))
+	compound := NewCompound()
+	// keys are written and parsed in insertion order, not sorted
+	compound.Set("z", FloatTag(float32(math.NaN())))
+	compound.Set("a", DoubleTag(math.Inf(1)))
+	compound.Set("m", DoubleTag(math.Inf(-1)))
+	compound.Set("longs", ListTag(list))
+	compound.Set("array", LongArrayTag([]int64{-1, 9007199254740993}))
+	compound.Set("strings", ListTag(NewList(TAG_STRING)))
+	compound.Set("end", ListTag(NewList(TAG_END)))
+	tag := CompoundTag(compound)
+
+	expected := `{"type":"compound","value":{` +
+		`"z":{"type":"float","value":"NaN"},` +
+		`"a":{"type":"double","value":"Infinity"},` +
+		`"m":{"type":"double","value":"-Infinity"},` +
+		`"longs":{"type":"list","element_type":"long","value":[{"type":"long","value":"9223372036854775807"},{"type":"long","value":"-9223372036854775808"}]},` +
+		`"array":{"type":"long_array","value":["-1","9007199254740993"]},` +
+		`"strings":{"type":"list","element_type":"string","value":[]},` +
+		`"end":{"type":"list","element_type":"end","value":[]}}}`
+	typed := tag.TypedJSON()
+	if string(typed) != expected {
+		t.Fatalf("written as\n%s\nexpected\n%s", typed, expected)
+	}
+
+	parsed, err := ParseTypedJSON(typed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retyped := parsed.TypedJSON(); !bytes.Equal(retyped, typed) {
+		t.Errorf("written as\n%s\nafter parsing", retyped)
+	}
+	parsedCompound := parsed.AsCompound()
+	if keys := parsedCompound.Keys(); len(keys) != 7 || keys[0] != "z" || keys[1] != "a" || keys[6] != "end" {
+		t.Errorf("parsed keys in order %v", keys)
+	}
+	if value := parsedCompound.Get("z").AsFloat(); !math.IsNaN(float64(value)) {
+		t.Errorf("NaN parsed as %v", value)
+	}
+	if value := parsedCompound.Get("m").AsDouble(); !math.IsInf(value, -1) {
+		t.Errorf("-Infinity parsed as %v", value)
+	}
+	if value := parsedCompound.Get("array").AsLongArray(); value[1] != 9007199254740993 {
+		t.Errorf("long above 2^53 parsed as %d", value[1])
+	}
+	if list := parsedCompound.Get("strings").AsList(); list.DataType != TAG_STRING || list.Len() != 0 {
+		t.Errorf("empty list parsed with type %s and %d elements", TypeName(list.DataType), list.Len())
+	}
+
+	// the element type of the empty list is encoded as well
+	encoded := bytes.Buffer{}
+	if err := Encode(&encoded, parsed); err != nil {
+		t.Fatal(err)
+	}
+	original := bytes.Buffer{}
+	if err := Encode(&original, &tag); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(encoded.Bytes(), original.Bytes()) {
+		t.Errorf("parsed json encodes to different bytes")
+	}
+}
+
+func TestPlainJSON(t *testing.T) {
+	tag, err := ParseSNBT(`{b:1b,l:5L,f:NaNf,list:[],longs:[L;1L,2L],s:"\"q\""}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"b":1,"l":5,"f":null,"list":[],"longs":[1,2],"s":"\"q\""}`
+	if plain := string(tag.PlainJSON()); plain != expected {
+		t.Errorf("written as %s, expected %s", plain, expected)
+	}
+}
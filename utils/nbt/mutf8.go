@@ -0,0 +1,79 @@
+package nbt
+
+import (
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// strings are stored as java's modified utf-8, null is encoded with two bytes and characters outside
+// the basic multilingual plane as two encoded surrogates
+
+// decodeModifiedUtf8 converts the data to utf-8, data that isn't valid modified utf-8 is kept as is
+func decodeModifiedUtf8(data []byte) string {
+	ascii := true
+	for _, b := range data {
+		if b == 0 || b >= 0x80 {
+			ascii = false
+			break
+		}
+	}
+	if ascii {
+		return string(data)
+	}
+
+	units := make([]uint16, 0, len(data))
+	for i := 0; i < len(data); {
+		b := data[i]
+		switch {
+		case b != 0 && b < 0x80:
+			units = append(units, uint16(b))
+			i++
+		case b&0xe0 == 0xc0 && i+1 < len(data) && data[i+1]&0xc0 == 0x80:
+			unit := uint16(b&0x1f)<<6 | uint16(data[i+1]&0x3f)
+			if unit != 0 && unit < 0x80 {
+				return string(data)
+			}
+			units = append(units, unit)
+			i += 2
+		case b&0xf0 == 0xe0 && i+2 < len(data) && data[i+1]&0xc0 == 0x80 && data[i+2]&0xc0 == 0x80:
+			unit := uint16(b&0x0f)<<12 | uint16(data[i+1]&0x3f)<<6 | uint16(data[i+2]&0x3f)
+			if unit < 0x800 {
+				return string(data)
+			}
+			units = append(units, unit)
+			i += 3
+		default:
+			return string(data)
+		}
+	}
+
+	// unpaired surrogates can't be represented in utf-8
+	for i := 0; i < len(units); i++ {
+		if utf16.IsSurrogate(rune(units[i])) {
+			if i+1 >= len(units) || utf16.DecodeRune(rune(units[i]), rune(units[i+1])) == utf8.RuneError {
+				return string(data)
+			}
+			i++
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+// encodeModifiedUtf8 converts the string to modified utf-8, strings that aren't valid utf-8 are written as is
+func encodeModifiedUtf8(value string) []byte {
+	if !utf8.ValidString(value) {
+		return []byte(value)
+	}
+	data := make([]byte, 0, len(value))
+	for _, unit := range utf16.Encode([]rune(value)) {
+		switch {
+		case unit != 0 && unit < 0x80:
+			data = append(data, byte(unit))
+		case unit < 0x800:
+			data = append(data, 0xc0|byte(unit>>6), 0x80|byte(unit&0x3f))
+		default:
+			data = append(data, 0xe0|byte(unit>>12), 0x80|byte(unit>>6&0x3f), 0x80|byte(unit&0x3f))
+		}
+	}
+	return data
+}
@@ -0,0 +1,399 @@
+package nbt
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrMissing   = errors.New("missing tag")
+	ErrWrongType = errors.New("wrong tag type")
+)
+
+// PathError is returned when a path can't be followed, At is the part of the path that failed
+type PathError struct {
+	Path string
+	At   string
+	Err  error
+}
+
+func (err *PathError) Error() string {
+	if err.At == "" {
+		return fmt.Sprintf("%s: %v", err.Path, err.Err)
+	}
+	return fmt.Sprintf("%s: %v at %s", err.Path, err.Err, err.At)
+}
+
+func (err *PathError) Unwrap() error {
+	return err.Err
+}
+
+const (
+	segmentKey = iota
+	segmentIndex
+	segmentWildcard
+)
+
+type segment struct {
+	kind  int
+	key   string
+	index int
+	// end of the segment in the source, used to report where a path failed
+	end int
+}
+
+// Path is a compiled query into a tag. Keys of compounds are separated by dots and list or array elements are
+// selected by index, e.g. tag.display.Lore[0]. Negative indices count from the end, keys containing dots or brackets
+// can be quoted ("key.with.dots") and * selects every value of a compound or list, e.g. tag.ExtraAttributes.enchantments.*
+type Path struct {
+	source    string
+	segments  []segment
+	wildcards int
+}
+
+// Match is a tag selected by a path, Keys are the keys or indices the wildcards of the path matched
+type Match struct {
+	Keys []string
+	Tag  WrappedTag
+}
+
+// CompilePath parses a path, the empty path selects the tag itself
+func CompilePath(path string) (*Path, error) {
+	parser := pathParser{source: path}
+	segments, err := parser.parse()
+	if err != nil {
+		return nil, fmt.Errorf("invalid path %q at %d: %w", path, parser.position, err)
+	}
+	compiled := &Path{source: path, segments: segments}
+	for _, segment := range segments {
+		if segment.kind == segmentWildcard {
+			compiled.wildcards++
+		}
+	}
+	return compiled, nil
+}
+
+// MustCompilePath is CompilePath for paths known at compile time, it panics if the path is invalid
+func MustCompilePath(path string) *Path {
+	compiled, err := CompilePath(path)
+	if err != nil {
+		panic(err)
+	}
+	return compiled
+}
+
+func (path *Path) String() string {
+	return path.source
+}
+
+// Get returns the selected tag, paths with wildcards return the first match
+func (path *Path) Get(tag WrappedTag) (*WrappedTag, error) {
+	if path.wildcards > 0 {
+		matches := path.All(tag)
+		if len(matches) == 0 {
+			return nil, &PathError{path.source, "", ErrMissing}
+		}
+		return &matches[0].Tag, nil
+	}
+
+	current := tag
+	for _, segment := range path.segments {
+		next, err := segment.step(current)
+		if err != nil {
+			return nil, &PathError{path.source, path.source[:segment.end], err}
+		}
+		current = next
+	}
+	return &current, nil
+}
+
+// All returns every selected tag in order, parts of the tag that don't match the path are skipped
+func (path *Path) All(tag WrappedTag) []Match {
+	matches := make([]Match, 0)
+	path.collect(tag, 0, nil, &matches)
+	return matches
+}
+
+func (path *Path) collect(tag WrappedTag, index int, keys []string, matches *[]Match) {
+	if index == len(path.segments) {
+		*matches = append(*matches, Match{keys, tag})
+		return
+	}
+
+	current := path.segments[index]
+	if current.kind != segmentWildcard {
+		if next, err := current.step(tag); err == nil {
+			path.collect(next, index+1, keys, matches)
+		}
+		return
+	}
+
+	// keys are copied as branches of the walk append to the same slice
+	visit := func(key string, value WrappedTag) {
+		branch := make([]string, len(keys), len(keys)+1)
+		copy(branch, keys)
+		path.collect(value, index+1, append(branch, key), matches)
+	}
+	switch tag.tagType {
+	case TAG_COMPOUND:
+		compound := tag.Tag.(*Compound)
+		for _, key := range compound.keys {
+			visit(key, compound.backing[key])
+		}
+	case TAG_LIST, TAG_BYTE_ARRAY, TAG_INT_ARRAY, TAG_LONG_ARRAY:
+		for i := range length(tag) {
+			value, _ := element(tag, i)
+			visit(strconv.Itoa(i), value)
+		}
+	}
+}
+
+func (segment segment) step(tag WrappedTag) (WrappedTag, error) {
+	switch segment.kind {
+	case segmentKey:
+		if tag.tagType != TAG_COMPOUND {
+			return WrappedTag{}, wrongType("Compound", tag.tagType)
+		}
+		value, ok := tag.Tag.(*Compound).backing[segment.key]
+		if !ok {
+			return WrappedTag{}, ErrMissing
+		}
+		return value, nil
+	case segmentIndex:
+		if tag.tagType != TAG_LIST && tag.tagType != TAG_BYTE_ARRAY && tag.tagType != TAG_INT_ARRAY && tag.tagType != TAG_LONG_ARRAY {
+			return WrappedTag{}, wrongType("List", tag.tagType)
+		}
+		index := segment.index
+		if index < 0 {
+			index += length(tag)
+		}
+		if index < 0 || index >= length(tag) {
+			return WrappedTag{}, ErrMissing
+		}
+		return element(tag, index)
+	}
+	return WrappedTag{}, fmt.Errorf("can't step into a wildcard")
+}
+
+func length(tag WrappedTag) int {
+	switch tag.tagType {
+	case TAG_LIST:
+		return tag.Tag.(*List).Len()
+	case TAG_BYTE_ARRAY:
+		return len(tag.Tag.([]byte))
+	case TAG_INT_ARRAY:
+		return len(tag.Tag.([]int32))
+	case TAG_LONG_ARRAY:
+		return len(tag.Tag.([]int64))
+	}
+	return 0
+}
+
+// element returns an element of a list or array, elements of arrays are wrapped as the matching number tag
+func element(tag WrappedTag, index int) (WrappedTag, error) {
+	switch tag.tagType {
+	case TAG_LIST:
+		return *tag.Tag.(*List).Get(index), nil
+	case TAG_BYTE_ARRAY:
+		return WrappedTag{tag.Tag.([]byte)[index], TAG_BYTE}, nil
+	case TAG_INT_ARRAY:
+		return WrappedTag{tag.Tag.([]int32)[index], TAG_INT}, nil
+	case TAG_LONG_ARRAY:
+		return WrappedTag{tag.Tag.([]int64)[index], TAG_LONG}, nil
+	}
+	return WrappedTag{}, wrongType("List", tag.tagType)
+}
+
+func wrongType(expected string, actual byte) error {
+	return fmt.Errorf("%w: expected %s, got %s", ErrWrongType, expected, TypeName(actual))
+}
+
+// getType returns the selected tag if it has one of the given types
+func (path *Path) getType(tag WrappedTag, expected string, tagTypes ...byte) (*WrappedTag, error) {
+	result, err := path.Get(tag)
+	if err != nil {
+		return nil, err
+	}
+	for _, tagType := range tagTypes {
+		if result.tagType == tagType {
+			return result, nil
+		}
+	}
+	return nil, &PathError{path.source, "", wrongType(expected, result.tagType)}
+}
+
+func (path *Path) GetByte(tag WrappedTag) (int8, error) {
+	result, err := path.getType(tag, "Byte", TAG_BYTE)
+	if err != nil {
+		return 0, err
+	}
+	return result.AsByte(), nil
+}
+
+func (path *Path) GetShort(tag WrappedTag) (int16, error) {
+	result, err := path.getType(tag, "Short", TAG_SHORT)
+	if err != nil {
+		return 0, err
+	}
+	return result.AsShort(), nil
+}
+
+func (path *Path) GetInt(tag WrappedTag) (int32, error) {
+	result, err := path.getType(tag, "Int", TAG_INT)
+	if err != nil {
+		return 0, err
+	}
+	return result.AsInt(), nil
+}
+
+func (path *Path) GetLong(tag WrappedTag) (int64, error) {
+	result, err := path.getType(tag, "Long", TAG_LONG)
+	if err != nil {
+		return 0, err
+	}
+	return result.AsLong(), nil
+}
+
+// GetInteger accepts every integer type, the hypixel api isn't consistent about the type of numbers
+func (path *Path) GetInteger(tag WrappedTag) (int64, error) {
+	result, err := path.getType(tag, "Integer", TAG_BYTE, TAG_SHORT, TAG_INT, TAG_LONG)
+	if err != nil {
+		return 0, err
+	}
+	value, _ := result.AsInteger()
+	return value, nil
+}
+
+func (path *Path) GetFloat(tag WrappedTag) (float32, error) {
+	result, err := path.getType(tag, "Float", TAG_FLOAT)
+	if err != nil {
+		return 0, err
+	}
+	return result.AsFloat(), nil
+}
+
+func (path *Path) GetDouble(tag WrappedTag) (float64, error) {
+	result, err := path.getType(tag, "Double", TAG_DOUBLE)
+	if err != nil {
+		return 0, err
+	}
+	return result.AsDouble(), nil
+}
+
+func (path *Path) GetString(tag WrappedTag) (string, error) {
+	result, err := path.getType(tag, "String", TAG_STRING)
+	if err != nil {
+		return "", err
+	}
+	return result.AsString(), nil
+}
+
+func (path *Path) GetList(tag WrappedTag) (*List, error) {
+	result, err := path.getType(tag, "List", TAG_LIST)
+	if err != nil {
+		return nil, err
+	}
+	return result.AsList(), nil
+}
+
+func (path *Path) GetCompound(tag WrappedTag) (*Compound, error) {
+	result, err := path.getType(tag, "Compound", TAG_COMPOUND)
+	if err != nil {
+		return nil, err
+	}
+	return result.AsCompound(), nil
+}
+
+type pathParser struct {
+	source   string
+	position int
+}
+
+func (parser *pathParser) parse() ([]segment, error) {
+	segments := make([]segment, 0)
+	for parser.position < len(parser.source) {
+		if len(segments) > 0 && parser.source[parser.position] != '[' {
+			if parser.source[parser.position] != '.' {
+				return nil, fmt.Errorf("expected . or [")
+			}
+			parser.position++
+		}
+
+		var next segment
+		var err error
+		if parser.position < len(parser.source) && parser.source[parser.position] == '[' {
+			next, err = parser.parseIndex()
+		} else {
+			next, err = parser.parseKey()
+		}
+		if err != nil {
+			return nil, err
+		}
+		next.end = parser.position
+		segments = append(segments, next)
+	}
+	return segments, nil
+}
+
+func (parser *pathParser) parseKey() (segment, error) {
+	if parser.position < len(parser.source) && parser.source[parser.position] == '"' {
+		key, err := parser.parseQuoted()
+		return segment{kind: segmentKey, key: key}, err
+	}
+
+	start := parser.position
+	for parser.position < len(parser.source) && !strings.ContainsRune(`.[]"`, rune(parser.source[parser.position])) {
+		parser.position++
+	}
+	key := parser.source[start:parser.position]
+	if key == "" {
+		return segment{}, fmt.Errorf("expected key")
+	}
+	if key == "*" {
+		return segment{kind: segmentWildcard}, nil
+	}
+	return segment{kind: segmentKey, key: key}, nil
+}
+
+func (parser *pathParser) parseQuoted() (string, error) {
+	parser.position++
+	builder := strings.Builder{}
+	for parser.position < len(parser.source) {
+		char := parser.source[parser.position]
+		parser.position++
+		switch char {
+		case '"':
+			return builder.String(), nil
+		case '\\':
+			if parser.position == len(parser.source) {
+				return "", fmt.Errorf("unterminated escape")
+			}
+			builder.WriteByte(parser.source[parser.position])
+			parser.position++
+		default:
+			builder.WriteByte(char)
+		}
+	}
+	return "", fmt.Errorf("unterminated quoted key")
+}
+
+func (parser *pathParser) parseIndex() (segment, error) {
+	parser.position++
+	end := strings.IndexByte(parser.source[parser.position:], ']')
+	if end < 0 {
+		return segment{}, fmt.Errorf("expected ]")
+	}
+	content := parser.source[parser.position : parser.position+end]
+	if content == "*" {
+		parser.position += end + 1
+		return segment{kind: segmentWildcard}, nil
+	}
+	index, err := strconv.Atoi(content)
+	if err != nil {
+		return segment{}, fmt.Errorf("invalid index %q", content)
+	}
+	parser.position += end + 1
+	return segment{kind: segmentIndex, index: index}, nil
+}
@@ -0,0 +1,165 @@
+package nbt
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+const pathTestSNBT = `{tag:{display:{Name:"name",Lore:["a","b","c"]},"key.with.dots":1,"quo\"te\\":2,` +
+	`ExtraAttributes:{enchantments:{sharpness:5,critical:6}}},bytes:[B;1B,2B],ints:[I;3,4],longs:[L;5L],count:1b}`
+
+func pathTestTag(t *testing.T) WrappedTag {
+	t.Helper()
+	tag, err := ParseSNBT(pathTestSNBT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *tag
+}
+
+func TestPathGet(t *testing.T) {
+	tag := pathTestTag(t)
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		// the empty path selects the tag itself
+		{"", tag.SNBT()},
+		{"tag.ExtraAttributes", "{enchantments:{sharpness:5,critical:6}}"},
+		{"tag.display.Name", `"name"`},
+		{"tag.display.Lore[0]", `"a"`},
+		{"tag.display.Lore[2]", `"c"`},
+		{"tag.display.Lore[-1]", `"c"`},
+		{"tag.display.Lore[-3]", `"a"`},
+		{"bytes[1]", "2b"},
+		{"ints[-1]", "4"},
+		{"longs[0]", "5L"},
+		{`tag."key.with.dots"`, "1"},
+		{`tag."quo\"te\\"`, "2"},
+		{`"tag".display."Name"`, `"name"`},
+		// paths with wildcards return the first match
+		{"tag.ExtraAttributes.enchantments.*", "5"},
+		{"tag.display.Lore[*]", `"a"`},
+	}
+	for _, test := range tests {
+		result, err := MustCompilePath(test.path).Get(tag)
+		if err != nil {
+			t.Errorf("%s: %v", test.path, err)
+			continue
+		}
+		if formatted := result.SNBT(); formatted != test.expected {
+			t.Errorf("%s: got %s, expected %s", test.path, formatted, test.expected)
+		}
+	}
+}
+
+func TestPathErrors(t *testing.T) {
+	tag := pathTestTag(t)
+	tests := []struct {
+		path string
+		err  error
+		at   string
+	}{
+		{"tag.missing", ErrMissing, "tag.missing"},
+		{"tag.missing.Name", ErrMissing, "tag.missing"},
+		{"tag.display.Lore[3]", ErrMissing, "tag.display.Lore[3]"},
+		{"tag.display.Lore[-4]", ErrMissing, "tag.display.Lore[-4]"},
+		{"bytes[2]", ErrMissing, "bytes[2]"},
+		{"tag.display.Name.x", ErrWrongType, "tag.display.Name.x"},
+		{"count[0]", ErrWrongType, "count[0]"},
+		{"ints.x", ErrWrongType, "ints.x"},
+		// wildcards without a match don't fail at a specific part
+		{"tag.display.*.x", ErrMissing, ""},
+		{"count.*", ErrMissing, ""},
+	}
+	for _, test := range tests {
+		_, err := MustCompilePath(test.path).Get(tag)
+		var pathError *PathError
+		if !errors.As(err, &pathError) {
+			t.Errorf("%s: expected a PathError, got %v", test.path, err)
+			continue
+		}
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: expected %v, got %v", test.path, test.err, err)
+		}
+		if errors.Is(err, ErrMissing) && errors.Is(err, ErrWrongType) {
+			t.Errorf("%s: %v is missing and of the wrong type", test.path, err)
+		}
+		if pathError.At != test.at {
+			t.Errorf("%s: failed at %q, expected %q", test.path, pathError.At, test.at)
+		}
+		if pathError.Path != test.path {
+			t.Errorf("%s: error has path %s", test.path, pathError.Path)
+		}
+	}
+}
+
+func TestPathTypedGetters(t *testing.T) {
+	tag := pathTestTag(t)
+
+	if name, err := MustCompilePath("tag.display.Name").GetString(tag); err != nil || name != "name" {
+		t.Errorf("GetString returned %q, %v", name, err)
+	}
+	if count, err := MustCompilePath("count").GetInteger(tag); err != nil || count != 1 {
+		t.Errorf("GetInteger returned %d, %v", count, err)
+	}
+
+	// the tag exists but has another type, the error is not about a part of the path
+	_, err := MustCompilePath("count").GetString(tag)
+	var pathError *PathError
+	if !errors.As(err, &pathError) || !errors.Is(err, ErrWrongType) || pathError.At != "" {
+		t.Errorf("GetString of a byte returned %v", err)
+	}
+	if _, err := MustCompilePath("missing").GetInt(tag); !errors.Is(err, ErrMissing) {
+		t.Errorf("GetInt of a missing tag returned %v", err)
+	}
+}
+
+func TestPathAll(t *testing.T) {
+	tag := pathTestTag(t)
+	tests := []struct {
+		path   string
+		keys   [][]string
+		values []string
+	}{
+		{"tag.ExtraAttributes.enchantments.*", [][]string{{"sharpness"}, {"critical"}}, []string{"5", "6"}},
+		{"tag.display.Lore[*]", [][]string{{"0"}, {"1"}, {"2"}}, []string{`"a"`, `"b"`, `"c"`}},
+		{"tag.display.Lore.*", [][]string{{"0"}, {"1"}, {"2"}}, []string{`"a"`, `"b"`, `"c"`}},
+		{"bytes[*]", [][]string{{"0"}, {"1"}}, []string{"1b", "2b"}},
+		{"ints.*", [][]string{{"0"}, {"1"}}, []string{"3", "4"}},
+		{"longs[*]", [][]string{{"0"}}, []string{"5L"}},
+		// parts that don't match the rest of the path are skipped
+		{"*.display.Name", [][]string{{"tag"}}, []string{`"name"`}},
+		{"tag.*.*", [][]string{{"display", "Name"}, {"display", "Lore"}, {"ExtraAttributes", "enchantments"}},
+			[]string{`"name"`, `["a","b","c"]`, "{sharpness:5,critical:6}"}},
+		{"tag.*.Lore[-1]", [][]string{{"display"}}, []string{`"c"`}},
+		{"count.*", [][]string{}, []string{}},
+		// paths without wildcards match at most once without keys
+		{"tag.display.Name", [][]string{nil}, []string{`"name"`}},
+		{"tag.missing", [][]string{}, []string{}},
+	}
+	for _, test := range tests {
+		matches := MustCompilePath(test.path).All(tag)
+		keys := make([][]string, 0)
+		values := make([]string, 0)
+		for _, match := range matches {
+			keys = append(keys, match.Keys)
+			values = append(values, match.Tag.SNBT())
+		}
+		if !slices.EqualFunc(keys, test.keys, slices.Equal) {
+			t.Errorf("%s: matched keys %q, expected %q", test.path, keys, test.keys)
+		}
+		if !slices.Equal(values, test.values) {
+			t.Errorf("%s: matched %q, expected %q", test.path, values, test.values)
+		}
+	}
+}
+
+func TestCompilePathErrors(t *testing.T) {
+	for _, path := range []string{"a..b", ".a", "a.", "a[", "a[x]", "a[]", "a[1", `"a`, `a."b`, `a."b\`, `a"b"`, "a]"} {
+		if compiled, err := CompilePath(path); err == nil {
+			t.Errorf("%s: compiled to %d segments", path, len(compiled.segments))
+		}
+	}
+}
@@ -0,0 +1,468 @@
+package nbt
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SNBT is the stringified nbt format of minecraft commands, e.g. {id:"minecraft:stone",Count:1b}
+
+var (
+	nonFiniteFloats    = map[string]float64{"NaN": math.NaN(), "Infinity": math.Inf(1), "-Infinity": math.Inf(-1)}
+	unquotedKeyPattern = regexp.MustCompile(`^[0-9A-Za-z_\-.+]+$`)
+	doublePattern      = regexp.MustCompile(`^[-+]?(?:[0-9]+[.]|[0-9]*[.][0-9]+)(?:e[-+]?[0-9]+)?$`)
+	suffixedPattern    = regexp.MustCompile(`^[-+]?(?:[0-9]+[.]?|[0-9]*[.][0-9]+)(?:e[-+]?[0-9]+)?$`)
+	integerPattern     = regexp.MustCompile(`^[-+]?(?:0|[1-9][0-9]*)$`)
+)
+
+// SNBT formats the tag in the compact format minecraft uses, ParseSNBT reads the result back into the same tag.
+// The only exception is the element type of empty lists, which isn't part of snbt and is parsed as TAG_END.
+// Non-finite floats aren't part of snbt either, they are written like java prints them (NaNf, Infinityd, -Infinityf)
+// which ParseSNBT reads back but minecraft reads as strings.
+func (tag WrappedTag) SNBT() string {
+	builder := strings.Builder{}
+	writeSNBT(&builder, tag.tagType, tag.Tag)
+	return builder.String()
+}
+
+func writeSNBT(builder *strings.Builder, tagType byte, tag Tag) {
+	switch tagType {
+	case TAG_BYTE:
+		builder.WriteString(fmt.Sprintf("%db", int8(tag.(byte))))
+	case TAG_SHORT:
+		builder.WriteString(fmt.Sprintf("%ds", tag.(int16)))
+	case TAG_INT:
+		builder.WriteString(fmt.Sprintf("%d", tag.(int32)))
+	case TAG_LONG:
+		builder.WriteString(fmt.Sprintf("%dL", tag.(int64)))
+	case TAG_FLOAT:
+		builder.WriteString(formatFloat(float64(tag.(float32)), 32) + "f")
+	case TAG_DOUBLE:
+		builder.WriteString(formatFloat(tag.(float64), 64) + "d")
+	case TAG_BYTE_ARRAY:
+		builder.WriteString("[B;")
+		for i, value := range tag.([]byte) {
+			if i > 0 {
+				builder.WriteByte(',')
+			}
+			builder.WriteString(fmt.Sprintf("%dB", int8(value)))
+		}
+		builder.WriteByte(']')
+	case TAG_STRING:
+		builder.WriteString(quoteSNBT(*tag.(*string)))
+	case TAG_LIST:
+		list := tag.(*List)
+		builder.WriteByte('[')
+		for i, value := range list.values {
+			if i > 0 {
+				builder.WriteByte(',')
+			}
+			writeSNBT(builder, list.DataType, value)
+		}
+		builder.WriteByte(']')
+	case TAG_COMPOUND:
+		compound := tag.(*Compound)
+		builder.WriteByte('{')
+		for i, key := range compound.keys {
+			if i > 0 {
+				builder.WriteByte(',')
+			}
+			if unquotedKeyPattern.MatchString(key) {
+				builder.WriteString(key)
+			} else {
+				builder.WriteString(quoteSNBT(key))
+			}
+			builder.WriteByte(':')
+			value := compound.backing[key]
+			writeSNBT(builder, value.tagType, value.Tag)
+		}
+		builder.WriteByte('}')
+	case TAG_INT_ARRAY:
+		builder.WriteString("[I;")
+		for i, value := range tag.([]int32) {
+			if i > 0 {
+				builder.WriteByte(',')
+			}
+			builder.WriteString(strconv.FormatInt(int64(value), 10))
+		}
+		builder.WriteByte(']')
+	case TAG_LONG_ARRAY:
+		builder.WriteString("[L;")
+		for i, value := range tag.([]int64) {
+			if i > 0 {
+				builder.WriteByte(',')
+			}
+			builder.WriteString(fmt.Sprintf("%dL", value))
+		}
+		builder.WriteByte(']')
+	}
+}
+
+// formatFloat returns the shortest representation that parses back to the same value, always with a decimal point like java
+func formatFloat(value float64, bitSize int) string {
+	switch {
+	case math.IsNaN(value):
+		return "NaN"
+	case math.IsInf(value, 1):
+		return "Infinity"
+	case math.IsInf(value, -1):
+		return "-Infinity"
+	}
+	formatted := strconv.FormatFloat(value, 'g', -1, bitSize)
+	mantissa, exponent, hasExponent := strings.Cut(formatted, "e")
+	if !strings.Contains(mantissa, ".") {
+		mantissa += ".0"
+	}
+	if hasExponent {
+		return mantissa + "e" + exponent
+	}
+	return mantissa
+}
+
+// quoteSNBT quotes the string with double quotes, or with single quotes if it contains double quotes but no single quotes
+func quoteSNBT(value string) string {
+	quote := byte(0)
+	builder := strings.Builder{}
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c == '\\' {
+			builder.WriteByte('\\')
+		} else if c == '"' || c == '\'' {
+			if quote == 0 {
+				if c == '"' {
+					quote = '\''
+				} else {
+					quote = '"'
+				}
+			}
+			if quote == c {
+				builder.WriteByte('\\')
+			}
+		}
+		builder.WriteByte(c)
+	}
+	if quote == 0 {
+		quote = '"'
+	}
+	return string(quote) + builder.String() + string(quote)
+}
+
+type SyntaxError struct {
+	Offset  int
+	Message string
+}
+
+func (err *SyntaxError) Error() string {
+	return fmt.Sprintf("invalid snbt at %d: %s", err.Offset, err.Message)
+}
+
+// ParseSNBT parses a tag in stringified nbt format, e.g. the output of WrappedTag.SNBT or a /give command
+func ParseSNBT(value string) (*WrappedTag, error) {
+	parser := snbtParser{value, 0}
+	tag, err := parser.readValue()
+	if err != nil {
+		return nil, err
+	}
+	parser.skipWhitespace()
+	if parser.offset != len(parser.input) {
+		return nil, parser.error("unexpected trailing data")
+	}
+	return tag, nil
+}
+
+type snbtParser struct {
+	input  string
+	offset int
+}
+
+func (parser *snbtParser) error(format string, args ...interface{}) error {
+	return &SyntaxError{parser.offset, fmt.Sprintf(format, args...)}
+}
+
+func (parser *snbtParser) skipWhitespace() {
+	for parser.offset < len(parser.input) && strings.IndexByte(" \t\n\r", parser.input[parser.offset]) >= 0 {
+		parser.offset++
+	}
+}
+
+func (parser *snbtParser) peek() byte {
+	parser.skipWhitespace()
+	if parser.offset >= len(parser.input) {
+		return 0
+	}
+	return parser.input[parser.offset]
+}
+
+func (parser *snbtParser) expect(c byte) error {
+	if parser.peek() != c {
+		return parser.error("expected '%c'", c)
+	}
+	parser.offset++
+	return nil
+}
+
+func (parser *snbtParser) readValue() (*WrappedTag, error) {
+	switch parser.peek() {
+	case '{':
+		return parser.readCompound()
+	case '[':
+		if parser.offset+2 < len(parser.input) && strings.IndexByte("BIL", parser.input[parser.offset+1]) >= 0 &&
+			parser.input[parser.offset+2] == ';' {
+			return parser.readArray()
+		}
+		return parser.readList()
+	case '"', '\'':
+		value, err := parser.readQuoted()
+		if err != nil {
+			return nil, err
+		}
+		tag := StringTag(value)
+		return &tag, nil
+	case 0:
+		return nil, parser.error("expected value")
+	}
+
+	start := parser.offset
+	token := parser.readUnquoted()
+	if token == "" {
+		parser.offset = start
+		return nil, parser.error("expected value")
+	}
+	tag := typeToken(token)
+	return &tag, nil
+}
+
+func (parser *snbtParser) readUnquoted() string {
+	parser.skipWhitespace()
+	start := parser.offset
+	for parser.offset < len(parser.input) && isUnquotedCharacter(parser.input[parser.offset]) {
+		parser.offset++
+	}
+	return parser.input[start:parser.offset]
+}
+
+func isUnquotedCharacter(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || strings.IndexByte("_-.+", c) >= 0
+}
+
+func (parser *snbtParser) readQuoted() (string, error) {
+	quote := parser.peek()
+	parser.offset++
+	builder := strings.Builder{}
+	for parser.offset < len(parser.input) {
+		c := parser.input[parser.offset]
+		parser.offset++
+		if c == quote {
+			return builder.String(), nil
+		}
+		if c == '\\' {
+			if parser.offset >= len(parser.input) {
+				break
+			}
+			escaped := parser.input[parser.offset]
+			if escaped != '\\' && escaped != '"' && escaped != '\'' {
+				return "", parser.error("invalid escape sequence \\%c", escaped)
+			}
+			parser.offset++
+			c = escaped
+		}
+		builder.WriteByte(c)
+	}
+	return "", parser.error("unterminated string")
+}
+
+// typeToken determines the type of an unquoted value like minecraft does, values that aren't numbers are strings
+func typeToken(token string) WrappedTag {
+	lower := strings.ToLower(token)
+	if value, ok := nonFiniteFloats[token[:len(token)-1]]; ok {
+		switch lower[len(lower)-1] {
+		case 'f':
+			return FloatTag(float32(value))
+		case 'd':
+			return DoubleTag(value)
+		}
+	}
+	if len(token) > 1 && suffixedPattern.MatchString(token[:len(token)-1]) {
+		number := token[:len(token)-1]
+		switch lower[len(lower)-1] {
+		case 'f':
+			if value, err := strconv.ParseFloat(number, 32); err == nil {
+				return FloatTag(float32(value))
+			}
+		case 'd':
+			if value, err := strconv.ParseFloat(number, 64); err == nil {
+				return DoubleTag(value)
+			}
+		}
+	}
+	if len(token) > 1 && integerPattern.MatchString(token[:len(token)-1]) {
+		number := token[:len(token)-1]
+		switch lower[len(lower)-1] {
+		case 'b':
+			if value, err := strconv.ParseInt(number, 10, 8); err == nil {
+				return ByteTag(int8(value))
+			}
+		case 's':
+			if value, err := strconv.ParseInt(number, 10, 16); err == nil {
+				return ShortTag(int16(value))
+			}
+		case 'l':
+			if value, err := strconv.ParseInt(number, 10, 64); err == nil {
+				return LongTag(value)
+			}
+		}
+	}
+	if integerPattern.MatchString(token) {
+		if value, err := strconv.ParseInt(token, 10, 32); err == nil {
+			return IntTag(int32(value))
+		}
+	}
+	if doublePattern.MatchString(lower) {
+		if value, err := strconv.ParseFloat(token, 64); err == nil {
+			return DoubleTag(value)
+		}
+	}
+	switch lower {
+	case "true":
+		return ByteTag(1)
+	case "false":
+		return ByteTag(0)
+	}
+	return StringTag(token)
+}
+
+func (parser *snbtParser) readCompound() (*WrappedTag, error) {
+	if err := parser.expect('{'); err != nil {
+		return nil, err
+	}
+	compound := NewCompound()
+	for parser.peek() != '}' {
+		var key string
+		if c := parser.peek(); c == '"' || c == '\'' {
+			quoted, err := parser.readQuoted()
+			if err != nil {
+				return nil, err
+			}
+			key = quoted
+		} else {
+			key = parser.readUnquoted()
+			if key == "" {
+				return nil, parser.error("expected key")
+			}
+		}
+		if err := parser.expect(':'); err != nil {
+			return nil, err
+		}
+		value, err := parser.readValue()
+		if err != nil {
+			return nil, err
+		}
+		compound.Set(key, *value)
+
+		if parser.peek() != ',' {
+			break
+		}
+		parser.offset++
+	}
+	if err := parser.expect('}'); err != nil {
+		return nil, err
+	}
+	tag := CompoundTag(compound)
+	return &tag, nil
+}
+
+func (parser *snbtParser) readList() (*WrappedTag, error) {
+	if err := parser.expect('['); err != nil {
+		return nil, err
+	}
+	list := NewList(TAG_END)
+	for parser.peek() != ']' {
+		start := parser.offset
+		value, err := parser.readValue()
+		if err != nil {
+			return nil, err
+		}
+		if err := list.Add(*value); err != nil {
+			parser.offset = start
+			return nil, parser.error("%v", err)
+		}
+
+		if parser.peek() != ',' {
+			break
+		}
+		parser.offset++
+	}
+	if err := parser.expect(']'); err != nil {
+		return nil, err
+	}
+	tag := ListTag(list)
+	return &tag, nil
+}
+
+func (parser *snbtParser) readArray() (*WrappedTag, error) {
+	if err := parser.expect('['); err != nil {
+		return nil, err
+	}
+	arrayType := parser.input[parser.offset]
+	parser.offset += 2
+
+	var elementType byte
+	switch arrayType {
+	case 'B':
+		elementType = TAG_BYTE
+	case 'I':
+		elementType = TAG_INT
+	case 'L':
+		elementType = TAG_LONG
+	default:
+		return nil, parser.error("invalid array type '%c'", arrayType)
+	}
+
+	values := make([]*WrappedTag, 0)
+	for parser.peek() != ']' {
+		start := parser.offset
+		value, err := parser.readValue()
+		if err != nil {
+			return nil, err
+		}
+		if value.tagType != elementType {
+			parser.offset = start
+			return nil, parser.error("can't add %s to a %s array", TypeName(value.tagType), TypeName(elementType))
+		}
+		values = append(values, value)
+
+		if parser.peek() != ',' {
+			break
+		}
+		parser.offset++
+	}
+	if err := parser.expect(']'); err != nil {
+		return nil, err
+	}
+
+	var tag WrappedTag
+	switch elementType {
+	case TAG_BYTE:
+		data := make([]byte, len(values))
+		for i, value := range values {
+			data[i] = value.Tag.(byte)
+		}
+		tag = ByteArrayTag(data)
+	case TAG_INT:
+		data := make([]int32, len(values))
+		for i, value := range values {
+			data[i] = value.Tag.(int32)
+		}
+		tag = IntArrayTag(data)
+	case TAG_LONG:
+		data := make([]int64, len(values))
+		for i, value := range values {
+			data[i] = value.Tag.(int64)
+		}
+		tag = LongArrayTag(data)
+	}
+	return &tag, nil
+}
@@ -0,0 +1,135 @@
+package nbt
+
+import (
+	"fmt"
+	"slices"
+)
+
+func NewCompound() *Compound {
+	return &Compound{backing: map[string]WrappedTag{}}
+}
+
+// Set adds or replaces a tag, replaced tags keep their position
+func (compound *Compound) Set(name string, tag WrappedTag) {
+	if _, ok := compound.backing[name]; !ok {
+		compound.keys = append(compound.keys, name)
+	}
+	compound.backing[name] = tag
+}
+
+func (compound *Compound) Remove(name string) {
+	if _, ok := compound.backing[name]; !ok {
+		return
+	}
+	delete(compound.backing, name)
+	compound.keys = slices.DeleteFunc(compound.keys, func(key string) bool { return key == name })
+}
+
+// NewList creates an empty list, lists created with TAG_END take the type of the first added tag
+func NewList(dataType byte) *List {
+	return &List{make([]Tag, 0), dataType}
+}
+
+func (list *List) Add(tag WrappedTag) error {
+	if list.DataType == TAG_END && len(list.values) == 0 {
+		list.DataType = tag.tagType
+	}
+	if tag.tagType != list.DataType {
+		return fmt.Errorf("can't add %s to a list of %s", TypeName(tag.tagType), TypeName(list.DataType))
+	}
+	list.values = append(list.values, tag.Tag)
+	return nil
+}
+
+func (list *List) Len() int {
+	return len(list.values)
+}
+
+// Get returns the tag at the index wrapped with the type of the list
+func (list *List) Get(index int) *WrappedTag {
+	return &WrappedTag{list.values[index], list.DataType}
+}
+
+func (tag WrappedTag) Type() byte {
+	return tag.tagType
+}
+
+func ByteTag(value int8) WrappedTag {
+	return WrappedTag{byte(value), TAG_BYTE}
+}
+
+func ShortTag(value int16) WrappedTag {
+	return WrappedTag{value, TAG_SHORT}
+}
+
+func IntTag(value int32) WrappedTag {
+	return WrappedTag{value, TAG_INT}
+}
+
+func LongTag(value int64) WrappedTag {
+	return WrappedTag{value, TAG_LONG}
+}
+
+func FloatTag(value float32) WrappedTag {
+	return WrappedTag{value, TAG_FLOAT}
+}
+
+func DoubleTag(value float64) WrappedTag {
+	return WrappedTag{value, TAG_DOUBLE}
+}
+
+func ByteArrayTag(value []byte) WrappedTag {
+	return WrappedTag{value, TAG_BYTE_ARRAY}
+}
+
+func StringTag(value string) WrappedTag {
+	return WrappedTag{&value, TAG_STRING}
+}
+
+func ListTag(value *List) WrappedTag {
+	return WrappedTag{value, TAG_LIST}
+}
+
+func CompoundTag(value *Compound) WrappedTag {
+	return WrappedTag{value, TAG_COMPOUND}
+}
+
+func IntArrayTag(value []int32) WrappedTag {
+	return WrappedTag{value, TAG_INT_ARRAY}
+}
+
+func LongArrayTag(value []int64) WrappedTag {
+	return WrappedTag{value, TAG_LONG_ARRAY}
+}
+
+func TypeName(tagType byte) string {
+	switch tagType {
+	case TAG_END:
+		return "End"
+	case TAG_BYTE:
+		return "Byte"
+	case TAG_SHORT:
+		return "Short"
+	case TAG_INT:
+		return "Int"
+	case TAG_LONG:
+		return "Long"
+	case TAG_FLOAT:
+		return "Float"
+	case TAG_DOUBLE:
+		return "Double"
+	case TAG_BYTE_ARRAY:
+		return "Byte Array"
+	case TAG_STRING:
+		return "String"
+	case TAG_LIST:
+		return "List"
+	case TAG_COMPOUND:
+		return "Compound"
+	case TAG_INT_ARRAY:
+		return "Int Array"
+	case TAG_LONG_ARRAY:
+		return "Long Array"
+	}
+	return fmt.Sprintf("Unknown(%d)", tagType)
+}
@@ -0,0 +1,61 @@
+package text
+
+import "strings"
+
+// Rarities in ascending order, the rarity line of the lore starts with one of them
+var Rarities = []string{"COMMON", "UNCOMMON", "RARE", "EPIC", "LEGENDARY", "MYTHIC", "DIVINE", "SPECIAL", "VERY SPECIAL", "ULTIMATE", "ADMIN"}
+
+// LoreFacts are read from the rarity line at the bottom of the lore, e.g. §d§l§ka§r §d§lMYTHIC DUNGEON SWORD §d§l§ka
+type LoreFacts struct {
+	Rarity string `json:"rarity,omitempty"`
+	// ItemType is the rest of the rarity line, e.g. SWORD or ACCESSORY, empty for items without type
+	ItemType string `json:"item_type,omitempty"`
+	Dungeon  bool   `json:"dungeon"`
+	// Recombobulated items have obfuscated characters around the rarity line
+	Recombobulated bool `json:"recombobulated"`
+}
+
+// ParseLore reads the facts from the lines of the lore, the facts are empty if there is no rarity line
+func ParseLore(lines []string) LoreFacts {
+	for i := len(lines) - 1; i >= 0; i-- {
+		spans := Parse(lines[i])
+		if strings.TrimSpace(Plain(spans)) == "" {
+			continue
+		}
+		return parseRarityLine(spans)
+	}
+	return LoreFacts{}
+}
+
+func parseRarityLine(spans []Span) LoreFacts {
+	facts := LoreFacts{}
+	builder := strings.Builder{}
+	for _, span := range spans {
+		if span.Obfuscated {
+			facts.Recombobulated = true
+			continue
+		}
+		builder.WriteString(span.Text)
+	}
+	line := strings.TrimSpace(builder.String())
+
+	// longest rarity first so VERY SPECIAL isn't read as SPECIAL
+	rarity := ""
+	for _, candidate := range Rarities {
+		if len(candidate) > len(rarity) && (line == candidate || strings.HasPrefix(line, candidate+" ")) {
+			rarity = candidate
+		}
+	}
+	if rarity == "" {
+		return LoreFacts{}
+	}
+	facts.Rarity = rarity
+
+	rest := strings.TrimSpace(strings.TrimPrefix(line, rarity))
+	if rest == "DUNGEON" || strings.HasPrefix(rest, "DUNGEON ") {
+		facts.Dungeon = true
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, "DUNGEON"))
+	}
+	facts.ItemType = rest
+	return facts
+}
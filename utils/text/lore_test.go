@@ -0,0 +1,35 @@
+package text
+
+import "testing"
+
+func TestParseLore(t *testing.T) {
+	tests := []struct {
+		name     string
+		lines    []string
+		expected LoreFacts
+	}{
+		{"rarity and type", []string{"§7Damage: §c+100", "", "§6§lLEGENDARY SWORD"}, LoreFacts{Rarity: "LEGENDARY", ItemType: "SWORD"}},
+		{"rarity without type", []string{"§f§lCOMMON"}, LoreFacts{Rarity: "COMMON"}},
+		{"dungeon item", []string{"§5§lEPIC DUNGEON BOW"}, LoreFacts{Rarity: "EPIC", ItemType: "BOW", Dungeon: true}},
+		{"dungeon without type", []string{"§5§lEPIC DUNGEON"}, LoreFacts{Rarity: "EPIC", Dungeon: true}},
+		{"type starting with dungeon", []string{"§9§lRARE DUNGEONEER"}, LoreFacts{Rarity: "RARE", ItemType: "DUNGEONEER"}},
+		{"longest rarity", []string{"§c§lVERY SPECIAL HATCESSORY"}, LoreFacts{Rarity: "VERY SPECIAL", ItemType: "HATCESSORY"}},
+		{"rarity prefix of a word", []string{"§f§lCOMMONER"}, LoreFacts{}},
+		{
+			"recombobulated",
+			[]string{"§d§l§ka§r §d§lMYTHIC DUNGEON SWORD §d§l§ka"},
+			LoreFacts{Rarity: "MYTHIC", ItemType: "SWORD", Dungeon: true, Recombobulated: true},
+		},
+		{"obfuscated text without rarity", []string{"§d§l§ka§r §d§lNOTHING §d§l§ka"}, LoreFacts{}},
+		{"trailing empty lines", []string{"§6§lLEGENDARY ACCESSORY", "§r", "  ", ""}, LoreFacts{Rarity: "LEGENDARY", ItemType: "ACCESSORY"}},
+		{"only the last line", []string{"§6§lLEGENDARY SWORD", "§7Click to view!"}, LoreFacts{}},
+		{"plain rarity line", []string{"DIVINE"}, LoreFacts{Rarity: "DIVINE"}},
+		{"unknown codes", []string{"§z§6§lLEGENDARY§y SWORD"}, LoreFacts{Rarity: "LEGENDARY", ItemType: "SWORD"}},
+		{"no lines", nil, LoreFacts{}},
+	}
+	for _, test := range tests {
+		if facts := ParseLore(test.lines); facts != test.expected {
+			t.Errorf("%s: parsed as %+v, expected %+v", test.name, facts, test.expected)
+		}
+	}
+}
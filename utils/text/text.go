@@ -0,0 +1,309 @@
+package text
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// FormatChar starts a legacy formatting code, e.g. §6 for gold or §l for bold
+const FormatChar = '§'
+
+var colorNames = map[byte]string{
+	'0': "black",
+	'1': "dark_blue",
+	'2': "dark_green",
+	'3': "dark_aqua",
+	'4': "dark_red",
+	'5': "dark_purple",
+	'6': "gold",
+	'7': "gray",
+	'8': "dark_gray",
+	'9': "blue",
+	'a': "green",
+	'b': "aqua",
+	'c': "red",
+	'd': "light_purple",
+	'e': "yellow",
+	'f': "white",
+}
+
+// Style uses the field names of json text components so spans can be used as components directly
+type Style struct {
+	// Color is the name of the color (e.g. gold) or #RRGGBB
+	Color         string `json:"color,omitempty"`
+	Bold          bool   `json:"bold,omitempty"`
+	Italic        bool   `json:"italic,omitempty"`
+	Underlined    bool   `json:"underlined,omitempty"`
+	Strikethrough bool   `json:"strikethrough,omitempty"`
+	Obfuscated    bool   `json:"obfuscated,omitempty"`
+}
+
+// Span is a part of a text with a single style
+type Span struct {
+	Text string `json:"text"`
+	Style
+}
+
+// Parse splits a text with legacy formatting codes into spans, unknown codes are dropped
+func Parse(text string) []Span {
+	return parse(text, Style{}, make([]Span, 0))
+}
+
+// parse appends the spans of the text to spans, base is the style §r resets to
+func parse(text string, base Style, spans []Span) []Span {
+	style := base
+	builder := strings.Builder{}
+	flush := func() {
+		if builder.Len() > 0 {
+			spans = appendSpan(spans, Span{builder.String(), style})
+			builder.Reset()
+		}
+	}
+
+	runes := []rune(text)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != FormatChar || i+1 == len(runes) {
+			builder.WriteRune(runes[i])
+			continue
+		}
+		i++
+		code := byte(0)
+		if runes[i] < 128 {
+			code = byte(runes[i]) | 0x20
+		}
+
+		next := style
+		switch {
+		case colorNames[code] != "":
+			// colors reset the formatting as in game
+			next = Style{Color: colorNames[code]}
+		case code == 'x':
+			if color, ok := parseHexColor(runes[i+1:]); ok {
+				next = Style{Color: color}
+				i += 12
+			}
+		case code == 'k':
+			next.Obfuscated = true
+		case code == 'l':
+			next.Bold = true
+		case code == 'm':
+			next.Strikethrough = true
+		case code == 'n':
+			next.Underlined = true
+		case code == 'o':
+			next.Italic = true
+		case code == 'r':
+			next = base
+		}
+		if next != style {
+			flush()
+			style = next
+		}
+	}
+	flush()
+	return spans
+}
+
+// parseHexColor reads the §R§R§G§G§B§B following §x
+func parseHexColor(runes []rune) (string, bool) {
+	if len(runes) < 12 {
+		return "", false
+	}
+	color := []rune{'#'}
+	for i := 0; i < 12; i += 2 {
+		if runes[i] != FormatChar || !strings.ContainsRune("0123456789abcdefABCDEF", runes[i+1]) {
+			return "", false
+		}
+		color = append(color, runes[i+1])
+	}
+	return strings.ToUpper(string(color)), true
+}
+
+// appendSpan merges spans with the same style
+func appendSpan(spans []Span, span Span) []Span {
+	if span.Text == "" {
+		return spans
+	}
+	if len(spans) > 0 && spans[len(spans)-1].Style == span.Style {
+		spans[len(spans)-1].Text += span.Text
+		return spans
+	}
+	return append(spans, span)
+}
+
+// Strip removes all formatting codes
+func Strip(text string) string {
+	return Plain(Parse(text))
+}
+
+// Plain joins the text of the spans without any formatting
+func Plain(spans []Span) string {
+	builder := strings.Builder{}
+	for _, span := range spans {
+		builder.WriteString(span.Text)
+	}
+	return builder.String()
+}
+
+// ToLegacy formats the spans with legacy formatting codes, §r is only written when formatting has to be removed
+func ToLegacy(spans []Span) string {
+	builder := strings.Builder{}
+	current := Style{}
+	for _, span := range spans {
+		if span.Style != current {
+			if removesFormatting(current, span.Style) {
+				builder.WriteString("§r")
+				current = Style{}
+			}
+			if span.Color != current.Color {
+				writeColor(&builder, span.Color)
+				current = Style{Color: span.Color}
+			}
+			writeFormat(&builder, !current.Obfuscated && span.Obfuscated, 'k')
+			writeFormat(&builder, !current.Bold && span.Bold, 'l')
+			writeFormat(&builder, !current.Strikethrough && span.Strikethrough, 'm')
+			writeFormat(&builder, !current.Underlined && span.Underlined, 'n')
+			writeFormat(&builder, !current.Italic && span.Italic, 'o')
+			current = span.Style
+		}
+		builder.WriteString(span.Text)
+	}
+	return builder.String()
+}
+
+func removesFormatting(from Style, to Style) bool {
+	return (from.Color != "" && to.Color == "") ||
+		(from.Obfuscated && !to.Obfuscated) ||
+		(from.Bold && !to.Bold) ||
+		(from.Strikethrough && !to.Strikethrough) ||
+		(from.Underlined && !to.Underlined) ||
+		(from.Italic && !to.Italic)
+}
+
+func writeColor(builder *strings.Builder, color string) {
+	if strings.HasPrefix(color, "#") && len(color) == 7 {
+		builder.WriteString("§x")
+		for _, char := range strings.ToLower(color[1:]) {
+			builder.WriteRune(FormatChar)
+			builder.WriteRune(char)
+		}
+		return
+	}
+	for code, name := range colorNames {
+		if name == color {
+			builder.WriteRune(FormatChar)
+			builder.WriteByte(code)
+			return
+		}
+	}
+}
+
+func writeFormat(builder *strings.Builder, write bool, code byte) {
+	if write {
+		builder.WriteRune(FormatChar)
+		builder.WriteByte(code)
+	}
+}
+
+// ToComponent returns the spans as json text component
+func ToComponent(spans []Span) []byte {
+	extra := spans
+	if extra == nil {
+		extra = make([]Span, 0)
+	}
+	// marshalling spans can't fail
+	data, _ := json.Marshal(struct {
+		Text  string `json:"text"`
+		Extra []Span `json:"extra"`
+	}{"", extra})
+	return data
+}
+
+// component are the fields of json text components that are kept, booleans are pointers as unset values are inherited
+type component struct {
+	Text          string            `json:"text"`
+	Translate     string            `json:"translate"`
+	Color         string            `json:"color"`
+	Bold          *bool             `json:"bold"`
+	Italic        *bool             `json:"italic"`
+	Underlined    *bool             `json:"underlined"`
+	Strikethrough *bool             `json:"strikethrough"`
+	Obfuscated    *bool             `json:"obfuscated"`
+	Extra         []json.RawMessage `json:"extra"`
+}
+
+// ParseComponent reads a json text component, legacy formatting codes inside the text are applied on top of the
+// style of the component. Translated components are kept as their translation key.
+func ParseComponent(data []byte) ([]Span, error) {
+	spans, _, err := parseComponent(data, Style{}, make([]Span, 0))
+	return spans, err
+}
+
+// parseComponent appends the spans of the component and returns the style the component has
+func parseComponent(data []byte, parent Style, spans []Span) ([]Span, Style, error) {
+	data = []byte(strings.TrimSpace(string(data)))
+	if len(data) == 0 {
+		return spans, parent, nil
+	}
+
+	switch data[0] {
+	case '"':
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return nil, parent, err
+		}
+		return parse(text, parent, spans), parent, nil
+	case '[':
+		var children []json.RawMessage
+		if err := json.Unmarshal(data, &children); err != nil {
+			return nil, parent, err
+		}
+		// the first element is the parent of the others
+		style := parent
+		for i, child := range children {
+			var err error
+			if i == 0 {
+				spans, style, err = parseComponent(child, parent, spans)
+			} else {
+				spans, _, err = parseComponent(child, style, spans)
+			}
+			if err != nil {
+				return nil, parent, err
+			}
+		}
+		return spans, style, nil
+	}
+
+	var value component
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, parent, err
+	}
+	style := parent
+	if value.Color != "" {
+		style.Color = value.Color
+	}
+	inherit(&style.Bold, value.Bold)
+	inherit(&style.Italic, value.Italic)
+	inherit(&style.Underlined, value.Underlined)
+	inherit(&style.Strikethrough, value.Strikethrough)
+	inherit(&style.Obfuscated, value.Obfuscated)
+
+	text := value.Text
+	if text == "" {
+		text = value.Translate
+	}
+	spans = parse(text, style, spans)
+	for _, child := range value.Extra {
+		var err error
+		if spans, _, err = parseComponent(child, style, spans); err != nil {
+			return nil, parent, err
+		}
+	}
+	return spans, style, nil
+}
+
+func inherit(target *bool, value *bool) {
+	if value != nil {
+		*target = *value
+	}
+}
@@ -0,0 +1,106 @@
+package text
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	gold := Style{Color: "gold"}
+	tests := []struct {
+		name     string
+		input    string
+		expected []Span
+	}{
+		{"plain", "plain", []Span{{"plain", Style{}}}},
+		{"empty", "", []Span{}},
+		{"color", "§6gold", []Span{{"gold", gold}}},
+		{"uppercase code", "§Cred", []Span{{"red", Style{Color: "red"}}}},
+		{"color and format", "§6§lbold", []Span{{"bold", Style{Color: "gold", Bold: true}}}},
+		{"every format", "§k§l§m§n§oall", []Span{{"all", Style{Bold: true, Italic: true, Underlined: true, Strikethrough: true, Obfuscated: true}}}},
+		{"color resets format", "§lbold§6gold", []Span{{"bold", Style{Bold: true}}, {"gold", gold}}},
+		{"reset", "§6§la§rb", []Span{{"a", Style{Color: "gold", Bold: true}}, {"b", Style{}}}},
+		{"reset then format", "§6a§r§ob", []Span{{"a", gold}, {"b", Style{Italic: true}}}},
+		{"same style is merged", "§6a§6b", []Span{{"ab", gold}}},
+		{"codes without text", "§6§l§r", []Span{}},
+		{"hex color", "§x§f§f§0§0§a§Ahex", []Span{{"hex", Style{Color: "#FF00AA"}}}},
+		{"incomplete hex color", "§x§fab", []Span{{"ab", Style{Color: "white"}}}},
+		{"dangling format char", "end§", []Span{{"end§", Style{}}}},
+		{"dangling format char after color", "§6end§", []Span{{"end§", gold}}},
+		{"only format char", "§", []Span{{"§", Style{}}}},
+		{"unknown code", "§za§6b", []Span{{"a", Style{}}, {"b", gold}}},
+		{"unknown code keeps style", "§6a§zb", []Span{{"ab", gold}}},
+		{"non ascii code", "§äa", []Span{{"a", Style{}}}},
+		{"non ascii text", "§6✪✪", []Span{{"✪✪", gold}}},
+	}
+	for _, test := range tests {
+		if spans := Parse(test.input); !slices.Equal(spans, test.expected) {
+			t.Errorf("%s: %q parsed as %v, expected %v", test.name, test.input, spans, test.expected)
+		}
+	}
+}
+
+func TestStrip(t *testing.T) {
+	tests := map[string]string{
+		"§6§lLEGENDARY §r§7SWORD": "LEGENDARY SWORD",
+		"§za§x§f§f§0§0§0§0b":      "ab",
+		"no codes":                "no codes",
+		"dangling§":               "dangling§",
+	}
+	for input, expected := range tests {
+		if stripped := Strip(input); stripped != expected {
+			t.Errorf("%q stripped to %q, expected %q", input, stripped, expected)
+		}
+	}
+}
+
+func TestToLegacy(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"§6§lbold", "§6§lbold"},
+		{"§6a§rb", "§6a§rb"},
+		{"§la§6b", "§la§r§6b"},
+		{"§6a§6§lb", "§6a§lb"},
+		{"§x§f§f§0§0§a§ahex", "§x§f§f§0§0§a§ahex"},
+		{"§za", "a"},
+	}
+	for _, test := range tests {
+		legacy := ToLegacy(Parse(test.input))
+		if legacy != test.expected {
+			t.Errorf("%q formatted as %q, expected %q", test.input, legacy, test.expected)
+		}
+		if spans := Parse(legacy); !slices.Equal(spans, Parse(test.input)) {
+			t.Errorf("%q parsed differently after formatting", test.input)
+		}
+	}
+}
+
+func TestParseComponent(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []Span
+	}{
+		{`"§6gold"`, []Span{{"gold", Style{Color: "gold"}}}},
+		{`{"text":"a","color":"gold","bold":true}`, []Span{{"a", Style{Color: "gold", Bold: true}}}},
+		{`{"text":"a","bold":true,"extra":[{"text":"b","bold":false},"c"]}`, []Span{{"a", Style{Bold: true}}, {"b", Style{}}, {"c", Style{Bold: true}}}},
+		{`[{"text":"a","color":"red"},"b"]`, []Span{{"ab", Style{Color: "red"}}}},
+		{`{"text":"§la","italic":true}`, []Span{{"a", Style{Bold: true, Italic: true}}}},
+		{`{"translate":"item.key"}`, []Span{{"item.key", Style{}}}},
+	}
+	for _, test := range tests {
+		spans, err := ParseComponent([]byte(test.input))
+		if err != nil {
+			t.Errorf("%s: %v", test.input, err)
+			continue
+		}
+		if !slices.Equal(spans, test.expected) {
+			t.Errorf("%s parsed as %v, expected %v", test.input, spans, test.expected)
+		}
+	}
+
+	if _, err := ParseComponent([]byte(`{"text":`)); err == nil {
+		t.Errorf("invalid component was parsed")
+	}
+}